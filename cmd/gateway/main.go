@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/everFinance/goether"
+	"github.com/everVision/everpay-kits/common"
+	"github.com/everVision/everpay-kits/gateway"
+	"github.com/everVision/everpay-kits/sdk"
+)
+
+var log = common.NewLog("gateway")
+
+// usage: EVERPAY_PRIVATE_KEY=<ecc private key hex> gateway -config config.json
+func main() {
+	cfgPath := flag.String("config", "config.json", "gateway config file")
+	flag.Parse()
+
+	cfg, err := gateway.LoadConfig(*cfgPath)
+	if err != nil {
+		log.Crit("load config failed", "err", err)
+		os.Exit(1)
+	}
+	signer, err := goether.NewSigner(os.Getenv("EVERPAY_PRIVATE_KEY"))
+	if err != nil {
+		log.Crit("invalid EVERPAY_PRIVATE_KEY", "err", err)
+		os.Exit(1)
+	}
+	s, err := sdk.New(signer, cfg.PayUrl)
+	if err != nil {
+		log.Crit("init sdk failed", "err", err)
+		os.Exit(1)
+	}
+
+	listen := cfg.Listen
+	if listen == "" {
+		listen = ":8080"
+	}
+	if err = gateway.New(s, cfg.Keys).Run(listen); err != nil {
+		log.Crit("gateway stopped", "err", err)
+		os.Exit(1)
+	}
+}
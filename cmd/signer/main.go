@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/everFinance/goar"
+	"github.com/everFinance/goether"
+	"github.com/everVision/everpay-kits/common"
+	"github.com/everVision/everpay-kits/sdk"
+	"github.com/everVision/everpay-kits/signer"
+)
+
+var log = common.NewLog("signer")
+
+// usage: EVERPAY_SIGNER_TOKEN=<token> EVERPAY_PRIVATE_KEY=<ecc private key hex> signer -policy policy.yaml -audit audit.log
+// or use arweave key file: signer -keyfile ar.json
+// audit records are chained by HMAC with EVERPAY_AUDIT_KEY if set, keep the logged audit head out of the host to detect truncation
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8081", "listen address")
+	keyFile := flag.String("keyfile", "", "arweave key file, use EVERPAY_PRIVATE_KEY if empty")
+	policyPath := flag.String("policy", "", "policy file, yaml or json")
+	auditPath := flag.String("audit", "", "audit log file")
+	flag.Parse()
+
+	var (
+		key interface{}
+		err error
+	)
+	if *keyFile != "" {
+		key, err = goar.NewSignerFromPath(*keyFile)
+	} else {
+		key, err = goether.NewSigner(os.Getenv("EVERPAY_PRIVATE_KEY"))
+	}
+	if err != nil {
+		log.Crit("load key failed", "err", err)
+		os.Exit(1)
+	}
+
+	srv, err := signer.New(key, os.Getenv("EVERPAY_SIGNER_TOKEN"))
+	if err != nil {
+		log.Crit("init signer failed", "err", err)
+		os.Exit(1)
+	}
+	if *policyPath != "" {
+		p, err := sdk.LoadPolicy(*policyPath)
+		if err != nil {
+			log.Crit("load policy failed", "err", err)
+			os.Exit(1)
+		}
+		srv.SetPolicy(p)
+	}
+	if *auditPath != "" {
+		sink, err := sdk.NewFileAuditSink(*auditPath, []byte(os.Getenv("EVERPAY_AUDIT_KEY")))
+		if err != nil {
+			log.Crit("open audit file failed", "err", err)
+			os.Exit(1)
+		}
+		seq, hash := sink.Head()
+		log.Info("audit head", "seq", seq, "hash", hash)
+		srv.SetAuditSink(sink)
+	}
+
+	if err = srv.Run(*listen); err != nil {
+		log.Crit("signer stopped", "err", err)
+		os.Exit(1)
+	}
+}
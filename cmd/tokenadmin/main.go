@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/everFinance/goether"
+	"github.com/everVision/everpay-kits/sdk"
+)
+
+// usage: EVERPAY_PRIVATE_KEY=<token owner private key hex> tokenadmin -url https://api.everpay.io -state tokens.yaml plan|apply
+// to change owner, get the challenge by `tokenadmin challenge <tokenTag> <newOwner>`, then set it as ownerChallenge
+// and the challenge msg signed by new owner as ownerProof in state file
+func main() {
+	payUrl := flag.String("url", "https://api.everpay.io", "everPay api url")
+	statePath := flag.String("state", "tokens.yaml", "desired token states, yaml or json, key: tokenTag")
+	flag.Parse()
+	cmd := flag.Arg(0)
+	if cmd != "plan" && cmd != "apply" && !(cmd == "challenge" && flag.NArg() == 3) {
+		fmt.Fprintln(os.Stderr, "usage: tokenadmin [-url url] [-state file] plan|apply|challenge <tokenTag> <newOwner>")
+		os.Exit(2)
+	}
+
+	signer, err := goether.NewSigner(os.Getenv("EVERPAY_PRIVATE_KEY"))
+	if err != nil {
+		fatal("invalid EVERPAY_PRIVATE_KEY", err)
+	}
+	s, err := sdk.New(signer, *payUrl)
+	if err != nil {
+		fatal("init sdk failed", err)
+	}
+	if cmd == "challenge" {
+		c, err := s.NewOwnerChallenge(flag.Arg(1), flag.Arg(2), 0)
+		if err != nil {
+			fatal("create challenge failed", err)
+		}
+		by, _ := json.MarshalIndent(c, "", "  ")
+		fmt.Printf("ownerChallenge:\n%s\n\nmsg to sign by new owner:\n%s\n", by, c)
+		return
+	}
+
+	states, err := sdk.LoadTokenStates(*statePath)
+	if err != nil {
+		fatal("load state failed", err)
+	}
+
+	plan, err := s.PlanTokenAdmin(states)
+	if err != nil {
+		fatal("plan failed", err)
+	}
+	if len(plan.Steps) == 0 {
+		fmt.Println("no changes")
+		return
+	}
+	for i, step := range plan.Steps {
+		fmt.Printf("%d. %s\n", i+1, step)
+	}
+	if cmd == "plan" {
+		return
+	}
+
+	results, err := s.ApplyTokenAdmin(plan)
+	for i, res := range results {
+		if res.Error != "" {
+			fmt.Printf("%d. failed: %s\n", i+1, res.Error)
+			continue
+		}
+		fmt.Printf("%d. applied: %s\n", i+1, strings.Join(res.EverHashes, ", "))
+	}
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func fatal(msg string, err error) {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", msg, err)
+	os.Exit(1)
+}
@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+	"time"
+)
+
+type Config struct {
+	PayUrl string   `json:"payUrl"` // everPay api url
+	Listen string   `json:"listen"` // e.g. ":8080"
+	Keys   []APIKey `json:"keys"`
+}
+
+// APIKey Limits: tokenTag -> max amount (include fee) can be spent by this key in LimitWindow,
+// tokens not in Limits can not be spent by this key.
+// notice: spent amounts are kept in memory, a restart starts new windows and
+// every gateway instance sharing the key counts its own limit
+type APIKey struct {
+	Name        string              `json:"name"`
+	Key         string              `json:"key"`
+	Limits      map[string]*big.Int `json:"limits"`
+	LimitWindow Duration            `json:"limitWindow"` // default 24h
+}
+
+// Duration json string like "24h"
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	s := ""
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	dur, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(dur)
+	return nil
+}
+
+func LoadConfig(path string) (Config, error) {
+	cfg := Config{}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	err = json.Unmarshal(b, &cfg)
+	return cfg, err
+}
@@ -0,0 +1,71 @@
+package gateway
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+)
+
+const defaultLimitWindow = 24 * time.Hour
+
+type spent struct {
+	amount      *big.Int
+	windowStart time.Time
+}
+
+// spendLimiter track the spent amount of api keys in fixed windows, in memory only
+type spendLimiter struct {
+	lock  sync.Mutex
+	spent map[string]map[string]*spent // key -> tokenTag -> spent
+}
+
+func newSpendLimiter() *spendLimiter {
+	return &spendLimiter{spent: make(map[string]map[string]*spent)}
+}
+
+// reserve add amount to spent of key, return ERR_SPEND_LIMIT_REACHED if over limit
+func (l *spendLimiter) reserve(key APIKey, tokenTag string, amount *big.Int) error {
+	limit, ok := key.Limits[tokenTag]
+	if !ok || limit == nil {
+		return schema.ERR_SPEND_LIMIT_REACHED
+	}
+	window := time.Duration(key.LimitWindow)
+	if window <= 0 {
+		window = defaultLimitWindow
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	tags, ok := l.spent[key.Key]
+	if !ok {
+		tags = make(map[string]*spent)
+		l.spent[key.Key] = tags
+	}
+	sp, ok := tags[tokenTag]
+	if !ok || time.Since(sp.windowStart) >= window {
+		sp = &spent{amount: big.NewInt(0), windowStart: time.Now()}
+		tags[tokenTag] = sp
+	}
+	total := new(big.Int).Add(sp.amount, amount)
+	if total.Cmp(limit) > 0 {
+		return schema.ERR_SPEND_LIMIT_REACHED
+	}
+	sp.amount = total
+	return nil
+}
+
+// release rollback the reserved amount when tx failed
+func (l *spendLimiter) release(key APIKey, tokenTag string, amount *big.Int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	sp, ok := l.spent[key.Key][tokenTag]
+	if !ok {
+		return
+	}
+	sp.amount = new(big.Int).Sub(sp.amount, amount)
+	if sp.amount.Sign() < 0 {
+		sp.amount = big.NewInt(0)
+	}
+}
@@ -0,0 +1,229 @@
+package gateway
+
+import (
+	"crypto/subtle"
+	"errors"
+	"math/big"
+	"net/http"
+
+	"github.com/everVision/everpay-kits/common"
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/sdk"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var log = common.NewLog("gateway")
+
+const (
+	APIKeyHeader = "X-API-KEY"
+	ctxKeyAPIKey = "apiKey"
+)
+
+// Server REST gateway of the everPay account of sdk, all spending requests need api key
+type Server struct {
+	sdk     *sdk.SDK
+	keys    []APIKey
+	limiter *spendLimiter
+	engine  *gin.Engine
+}
+
+type TransferReq struct {
+	TokenTag string `json:"tokenTag" binding:"required"`
+	Amount   string `json:"amount" binding:"required"` // integer amount, not include fee
+	To       string `json:"to" binding:"required"`
+	Data     string `json:"data"`
+}
+
+type WithdrawReq struct {
+	TokenTag  string `json:"tokenTag" binding:"required"`
+	Amount    string `json:"amount" binding:"required"` // integer amount, not include fee
+	ChainType string `json:"chainType" binding:"required"`
+	To        string `json:"to" binding:"required"`
+}
+
+type RespTx struct {
+	EverHash string             `json:"everHash"`
+	Tx       schema.Transaction `json:"tx"`
+}
+
+func New(s *sdk.SDK, keys []APIKey) *Server {
+	srv := &Server{
+		sdk:     s,
+		keys:    keys,
+		limiter: newSpendLimiter(),
+		engine:  gin.New(),
+	}
+	srv.engine.Use(gin.Recovery(), common.CORSMiddleware())
+
+	srv.engine.GET("/info", srv.getInfo)
+	v1 := srv.engine.Group("/", srv.auth())
+	{
+		v1.GET("/balances", srv.getBalances)
+		v1.GET("/balance/:tag", srv.getBalance)
+		v1.GET("/tx/:hash", srv.getTx)
+		v1.POST("/transfer", srv.transfer)
+		v1.POST("/withdraw", srv.withdraw)
+	}
+	return srv
+}
+
+func (s *Server) Handler() http.Handler {
+	return s.engine
+}
+
+func (s *Server) Run(addr string) error {
+	log.Info("gateway listening", "addr", addr, "accId", s.sdk.AccId)
+	return s.engine.Run(addr)
+}
+
+func (s *Server) auth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := s.apiKey(c.GetHeader(APIKeyHeader))
+		if !ok {
+			errorResponse(c, http.StatusUnauthorized, schema.ERR_INVALID_API_KEY)
+			c.Abort()
+			return
+		}
+		c.Set(ctxKeyAPIKey, key)
+		c.Next()
+	}
+}
+
+func (s *Server) apiKey(k string) (APIKey, bool) {
+	if k == "" {
+		return APIKey{}, false
+	}
+	for _, key := range s.keys {
+		if subtle.ConstantTimeCompare([]byte(key.Key), []byte(k)) == 1 {
+			return key, true
+		}
+	}
+	return APIKey{}, false
+}
+
+func (s *Server) getInfo(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"accId": s.sdk.AccId,
+	})
+}
+
+func (s *Server) getBalances(c *gin.Context) {
+	balances, err := s.sdk.Cli.Balances(s.sdk.AccId)
+	if err != nil {
+		errorResponse(c, http.StatusBadGateway, err)
+		return
+	}
+	c.JSON(http.StatusOK, balances)
+}
+
+func (s *Server) getBalance(c *gin.Context) {
+	balance, err := s.sdk.Cli.Balance(c.Param("tag"), s.sdk.AccId)
+	if err != nil {
+		errorResponse(c, http.StatusBadGateway, err)
+		return
+	}
+	c.JSON(http.StatusOK, balance)
+}
+
+// getTx only return the txs sent by the gateway account
+func (s *Server) getTx(c *gin.Context) {
+	tx, err := s.sdk.Cli.TxByHash(c.Param("hash"))
+	if err != nil {
+		errorResponse(c, http.StatusNotFound, err)
+		return
+	}
+	if tx.Tx == nil || utils.FormatAccId(tx.Tx.From) != utils.FormatAccId(s.sdk.AccId) {
+		errorResponse(c, http.StatusNotFound, schema.ERR_TX_NOT_FOUND)
+		return
+	}
+	c.JSON(http.StatusOK, tx)
+}
+
+func (s *Server) transfer(c *gin.Context) {
+	req := TransferReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Data) > schema.MaxTxDataLength {
+		errorResponse(c, http.StatusBadRequest, schema.ERR_LARGER_DATA)
+		return
+	}
+	quote, err := s.sdk.Fees.Quote(schema.TxActionTransfer, req.TokenTag, "")
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	s.send(c, quote, req.Amount, func(amount *big.Int) (*schema.Transaction, error) {
+		return s.sdk.TransferWithQuote(quote, amount, req.To, req.Data)
+	})
+}
+
+func (s *Server) withdraw(c *gin.Context) {
+	req := WithdrawReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	quote, err := s.sdk.Fees.Quote(schema.TxActionBurn, req.TokenTag, req.ChainType)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	s.send(c, quote, req.Amount, func(amount *big.Int) (*schema.Transaction, error) {
+		return s.sdk.WithdrawWithQuote(quote, amount, req.To)
+	})
+}
+
+// send reserve amount + fee from the spend limit of api key, then send tx,
+// the reservation is kept if the tx may have been submitted
+func (s *Server) send(c *gin.Context, quote schema.FeeQuote, amountStr string, sendTx func(amount *big.Int) (*schema.Transaction, error)) {
+	key := c.MustGet(ctxKeyAPIKey).(APIKey)
+	amount, ok := new(big.Int).SetString(amountStr, 10)
+	if !ok || amount.Sign() <= 0 {
+		errorResponse(c, http.StatusBadRequest, schema.ERR_INVALID_AMOUNT)
+		return
+	}
+	_, total, err := s.sdk.Fees.GrossUp(quote, amount)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	if err = s.limiter.reserve(key, quote.TokenTag, total); err != nil {
+		log.Warn("spend limit reached", "key", key.Name, "tokenTag", quote.TokenTag, "amount", total)
+		errorResponse(c, http.StatusForbidden, err)
+		return
+	}
+
+	tx, err := sendTx(amount)
+	if err != nil {
+		if rejected(err) {
+			s.limiter.release(key, quote.TokenTag, total)
+		}
+		log.Error("send tx failed", "key", key.Name, "action", quote.Action, "tokenTag", quote.TokenTag, "amount", amount, "err", err)
+		status := http.StatusBadGateway
+		if errors.Is(err, schema.ERR_TOKEN_NOT_EXIST) || errors.Is(err, schema.ERR_FEE_QUOTE_EXPIRED) {
+			status = http.StatusBadRequest
+		}
+		if errors.As(err, &schema.PolicyViolation{}) {
+			status = http.StatusForbidden
+		}
+		errorResponse(c, status, err)
+		return
+	}
+	log.Info("send tx success", "key", key.Name, "action", tx.Action, "tokenTag", quote.TokenTag,
+		"amount", tx.Amount, "fee", tx.Fee, "to", tx.To, "everHash", tx.HexHash())
+	c.JSON(http.StatusOK, RespTx{EverHash: tx.HexHash(), Tx: *tx})
+}
+
+// rejected the tx is definitely not accepted by everPay
+func rejected(err error) bool {
+	return errors.As(err, &schema.RespErr{}) || errors.As(err, &schema.PolicyViolation{}) ||
+		errors.Is(err, schema.ERR_TOKEN_NOT_EXIST) || errors.Is(err, schema.ERR_NOT_JSON_DATA) ||
+		errors.Is(err, schema.ERR_FEE_QUOTE_EXPIRED) || errors.Is(err, schema.ERR_FEE_QUOTE_MISMATCH)
+}
+
+func errorResponse(c *gin.Context, status int, err error) {
+	c.JSON(status, schema.RespErr{Err: err.Error()})
+}
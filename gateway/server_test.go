@@ -0,0 +1,199 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/everFinance/goether"
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/sdk"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTag = "ethereum-usdt-0xdac17f958d2ee523a2206206994597c13d831ec7"
+
+// testPay state of the mock everPay api
+type testPay struct {
+	txs          []schema.Transaction // submitted by /tx
+	txResps      []schema.TxResponse  // served by /tx/:hash with the submitted txs
+	submitStatus int                  // /tx response status with submitBody if not 0
+	submitBody   string
+}
+
+// newTestPayServer mock everPay api, submitted txs are saved to pay.txs
+func newTestPayServer(pay *testPay) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/info":
+			json.NewEncoder(w).Encode(schema.Info{TokenList: []schema.TokenInfo{{
+				Tag: testTag, Symbol: "USDT", ChainType: "ethereum", ChainID: "1", TransferFee: "1",
+				ID: "0xdac17f958d2ee523a2206206994597c13d831ec7",
+			}}})
+		case strings.HasPrefix(r.URL.Path, "/fee/"):
+			json.NewEncoder(w).Encode(schema.Fee{Fee: schema.TokenFee{
+				TokenTag: testTag, TransferFee: "1", BurnFeeMap: map[string]string{"ethereum": "10"},
+			}})
+		case r.URL.Path == "/tx" && r.Method == "POST":
+			if pay.submitStatus != 0 {
+				w.WriteHeader(pay.submitStatus)
+				w.Write([]byte(pay.submitBody))
+				return
+			}
+			tx := schema.Transaction{}
+			json.NewDecoder(r.Body).Decode(&tx)
+			pay.txs = append(pay.txs, tx)
+			w.Write([]byte(`{"status":"ok"}`))
+		case strings.HasPrefix(r.URL.Path, "/tx/"):
+			everHash := strings.TrimPrefix(r.URL.Path, "/tx/")
+			for _, tx := range pay.txs {
+				if tx.HexHash() == everHash {
+					json.NewEncoder(w).Encode(schema.Tx{Tx: &schema.TxResponse{
+						Action: tx.Action, From: tx.From, To: tx.To, Amount: tx.Amount, EverHash: everHash,
+					}})
+					return
+				}
+			}
+			for i := range pay.txResps {
+				if pay.txResps[i].EverHash == everHash {
+					json.NewEncoder(w).Encode(schema.Tx{Tx: &pay.txResps[i]})
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"err_not_found"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"err_not_found"}`))
+		}
+	}))
+}
+
+func newTestServer(t *testing.T, pay *testPay) (*Server, func()) {
+	gin.SetMode(gin.TestMode)
+	paySrv := newTestPayServer(pay)
+	signer, err := goether.NewSigner("ad1dcf8f1c449e7af21a7b8341eba5f053055819fff9948f1251ea94a0184cae")
+	assert.NoError(t, err)
+	s, err := sdk.New(signer, paySrv.URL)
+	assert.NoError(t, err)
+
+	srv := New(s, []APIKey{{
+		Name:   "shop",
+		Key:    "key-01",
+		Limits: map[string]*big.Int{testTag: big.NewInt(100)},
+	}})
+	return srv, paySrv.Close
+}
+
+func doReq(srv *Server, method, path, key string, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(APIKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(w, req)
+	return w
+}
+
+func TestServer_Auth(t *testing.T) {
+	pay := &testPay{}
+	srv, closeFn := newTestServer(t, pay)
+	defer closeFn()
+
+	w := doReq(srv, "POST", "/transfer", "", TransferReq{TokenTag: testTag, Amount: "1", To: "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = doReq(srv, "POST", "/transfer", "key-02", TransferReq{TokenTag: testTag, Amount: "1", To: "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"})
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, 0, len(pay.txs))
+}
+
+func TestServer_SpendLimit(t *testing.T) {
+	pay := &testPay{}
+	srv, closeFn := newTestServer(t, pay)
+	defer closeFn()
+	to := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+
+	// 49 + fee 1
+	w := doReq(srv, "POST", "/transfer", "key-01", TransferReq{TokenTag: testTag, Amount: "49", To: to})
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := RespTx{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "49", resp.Tx.Amount)
+	assert.Equal(t, "1", resp.Tx.Fee)
+
+	// 40 + fee 10
+	w = doReq(srv, "POST", "/withdraw", "key-01", WithdrawReq{TokenTag: testTag, Amount: "40", ChainType: "ethereum", To: to})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// limit 100 reached
+	w = doReq(srv, "POST", "/transfer", "key-01", TransferReq{TokenTag: testTag, Amount: "1", To: to})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), schema.ERR_SPEND_LIMIT_REACHED.Error())
+	assert.Equal(t, 2, len(pay.txs))
+
+	// invalid amount
+	w = doReq(srv, "POST", "/transfer", "key-01", TransferReq{TokenTag: testTag, Amount: "-1", To: to})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestServer_SpendLimitRelease(t *testing.T) {
+	pay := &testPay{}
+	srv, closeFn := newTestServer(t, pay)
+	defer closeFn()
+	to := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+
+	// rejected by everPay, 50 is released
+	pay.submitStatus, pay.submitBody = http.StatusBadRequest, `{"error":"err_invalid_signature"}`
+	w := doReq(srv, "POST", "/transfer", "key-01", TransferReq{TokenTag: testTag, Amount: "49", To: to})
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	// ambiguous error, tx may be submitted, 50 is kept
+	pay.submitStatus, pay.submitBody = http.StatusBadGateway, "bad gateway"
+	w = doReq(srv, "POST", "/transfer", "key-01", TransferReq{TokenTag: testTag, Amount: "49", To: to})
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	pay.submitStatus = 0
+	w = doReq(srv, "POST", "/transfer", "key-01", TransferReq{TokenTag: testTag, Amount: "49", To: to})
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = doReq(srv, "POST", "/transfer", "key-01", TransferReq{TokenTag: testTag, Amount: "1", To: to})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), schema.ERR_SPEND_LIMIT_REACHED.Error())
+	assert.Equal(t, 1, len(pay.txs))
+}
+
+func TestServer_GetTx(t *testing.T) {
+	pay := &testPay{}
+	srv, closeFn := newTestServer(t, pay)
+	defer closeFn()
+	pay.txResps = []schema.TxResponse{{
+		Action: schema.TxActionTransfer, From: "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223",
+		To: "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82", Amount: "1", EverHash: "0x01",
+	}}
+
+	w := doReq(srv, "POST", "/transfer", "key-01", TransferReq{TokenTag: testTag, Amount: "1", To: "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	resp := RespTx{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	w = doReq(srv, "GET", "/tx/"+resp.EverHash, "key-01", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	tx := schema.Tx{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tx))
+	assert.Equal(t, resp.EverHash, tx.Tx.EverHash)
+
+	// tx received by gateway account, not sent by it
+	w = doReq(srv, "GET", "/tx/0x01", "key-01", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), schema.ERR_TX_NOT_FOUND.Error())
+	w = doReq(srv, "GET", "/tx/0x02", "key-01", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = doReq(srv, "GET", "/tx/"+resp.EverHash, "", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -29,6 +29,38 @@ const (
 	BetaEverpayOrg    = "https://beta.everpay.io"
 )
 
+// MailVerifyExpiration register tx must be submitted in 5 minutes after MailVerify.Timestamp
+const MailVerifyExpiration = 300 // second
+
+// MailVerify email verification for eid account register,
+// everPay sends the code to the email and signs it after the code is verified
+type MailVerify struct {
+	Timestamp int64  `json:"timestamp"` // second
+	Code      string `json:"code"`
+	Sig       string `json:"sig"` // everPay owner sig, msg: timestamp + code + everId
+}
+
+// RegisterData register tx data of eid account
+type RegisterData struct {
+	MailVerify MailVerify `json:"mailVerify"`
+	Public     string     `json:"public"`     // base64 encoded public
+	PublicType string     `json:"publicType"` // ECDSA, RSA or FIDO2
+}
+
+// SetPublicData set tx data to manage the public keys of eid account
+type SetPublicData struct {
+	Operate    string `json:"operate"`              // addPublic or removePublic
+	PublicId   string `json:"publicId,omitempty"`   // removePublic
+	PublicType string `json:"publicType,omitempty"` // addPublic: ECDSA, RSA or FIDO2
+	Public     string `json:"public,omitempty"`     // addPublic: base64 encoded public
+}
+
+type AccPublic struct {
+	Id    string `json:"id"`
+	Type  string `json:"type"`
+	Value string `json:"value"` // base64 encoded public
+}
+
 type Authn struct {
 	Id                string `json:"id"`
 	RawId             string `json:"rawId"`
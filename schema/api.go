@@ -1,5 +1,11 @@
 package schema
 
+import (
+	"math/big"
+	"sort"
+	"strings"
+)
+
 type RespErr struct {
 	Err string `json:"error"`
 }
@@ -8,6 +14,17 @@ func (r RespErr) Error() string {
 	return r.Err
 }
 
+const (
+	WithdrawStatusPending   = "pending"   // burn tx not found on everPay
+	WithdrawStatusPackaged  = "packaged"  // burn tx packaged on everPay
+	WithdrawStatusConfirmed = "confirmed" // burn tx confirmed on everPay
+	WithdrawStatusBroadcast = "broadcast" // withdraw tx sent to target chain
+	WithdrawStatusSuccess   = "success"   // withdraw tx confirmed on target chain
+	WithdrawStatusFailed    = "failed"    // withdraw tx failed on target chain or invalid burn tx
+	WithdrawStatusRefunded  = "refunded"  // burn amount refunded on everPay
+	WithdrawStatusTimeout   = "timeout"
+)
+
 type WithdrawTxResponse struct {
 	EverHash    string
 	Token       string
@@ -89,6 +106,14 @@ type AccBalances struct {
 type Txs struct {
 	Txs         []TxResponse `json:"txs"`
 	HasNextPage bool         `json:"hasNextPage"`
+
+	// NextCursor is the rawId of the last tx returned by server, use it as the startCursor of next page.
+	// txs may be filtered by client side TxOpts, so the last tx of Txs is not always the cursor
+	NextCursor int64 `json:"-"`
+
+	// Unverified txs failed to verify when Client.SetVerifyTx enabled, key: everHash, val: verify err.
+	// they are removed from Txs, server generated txs like cross-chain mint may not be signed by From
+	Unverified map[string]error `json:"-"`
 }
 
 type AccTxs struct {
@@ -124,14 +149,110 @@ type RespAcc struct {
 	PublicValues map[string]string `json:"publicValues"` // key: publicId, val: public base64encode
 }
 
+// Publics return account public keys sorted by publicId
+func (r RespAcc) Publics() []AccPublic {
+	pubs := make([]AccPublic, 0, len(r.PublicValues))
+	for id, val := range r.PublicValues {
+		pubs = append(pubs, AccPublic{
+			Id:    id,
+			Type:  r.PublicType[id],
+			Value: val,
+		})
+	}
+	sort.Slice(pubs, func(i, j int) bool {
+		return pubs[i].Id < pubs[j].Id
+	})
+	return pubs
+}
+
 type RespRegister struct {
 	Sig       string `json:"sig"`
 	Timestamp int64  `json:"timestamp"`
 }
 
 type TxOpts struct {
+	// server side filters
 	Address       string
 	TokenTag      string
 	Action        string
 	WithoutAction string
+
+	// client side filters, if Address/TokenTag/Action is empty,
+	// From/To, single TokenTags and single Actions are also sent to server to narrow the result
+	From      string   // tx.From, case-insensitive
+	To        string   // tx.To, case-insensitive
+	Actions   []string // tx.Action is one of Actions
+	TokenTags []string // tx tag is one of TokenTags
+	Status    string   // confirmed or packaged
+	StartTime int64    // tx.Timestamp >= StartTime, same unit as TxResponse.Timestamp
+	EndTime   int64    // tx.Timestamp <= EndTime
+	MinAmount *big.Int // tx.Amount >= MinAmount
+	MaxAmount *big.Int // tx.Amount <= MaxAmount
+}
+
+// ServerQuery return the filters sent to everPay server
+func (o TxOpts) ServerQuery() (address, tokenTag, action, withoutAction string) {
+	address, tokenTag, action, withoutAction = o.Address, o.TokenTag, o.Action, o.WithoutAction
+	if address == "" {
+		if o.From != "" {
+			address = o.From
+		} else if o.To != "" {
+			address = o.To
+		}
+	}
+	if tokenTag == "" && len(o.TokenTags) == 1 {
+		tokenTag = o.TokenTags[0]
+	}
+	if action == "" && len(o.Actions) == 1 {
+		action = o.Actions[0]
+	}
+	return
+}
+
+// Match check tx by client side filters
+func (o TxOpts) Match(tx TxResponse) bool {
+	if o.From != "" && !strings.EqualFold(o.From, tx.From) {
+		return false
+	}
+	if o.To != "" && !strings.EqualFold(o.To, tx.To) {
+		return false
+	}
+	if len(o.Actions) > 0 && !ContainsStr(o.Actions, tx.Action, false) {
+		return false
+	}
+	if len(o.TokenTags) > 0 && !ContainsStr(o.TokenTags, tx.Tag(), true) {
+		return false
+	}
+	if o.Status != "" && o.Status != tx.Status {
+		return false
+	}
+	if o.StartTime > 0 && tx.Timestamp < o.StartTime {
+		return false
+	}
+	if o.EndTime > 0 && tx.Timestamp > o.EndTime {
+		return false
+	}
+	if o.MinAmount != nil || o.MaxAmount != nil {
+		amount, ok := new(big.Int).SetString(tx.Amount, 10)
+		if !ok {
+			return false
+		}
+		if o.MinAmount != nil && amount.Cmp(o.MinAmount) < 0 {
+			return false
+		}
+		if o.MaxAmount != nil && amount.Cmp(o.MaxAmount) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsStr report whether s is in arr
+func ContainsStr(arr []string, s string, ignoreCase bool) bool {
+	for _, v := range arr {
+		if v == s || (ignoreCase && strings.EqualFold(v, s)) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,27 @@
+package schema
+
+const (
+	ProposalStatusPending   = "pending"
+	ProposalStatusExecuted  = "executed" // approved and submitted
+	ProposalStatusFailed    = "failed"   // approved but tx rejected by everPay
+	ProposalStatusCancelled = "cancelled"
+	ProposalStatusExpired   = "expired"
+)
+
+// Proposal unsigned tx waiting for approvals, Approvals: approver -> sig of ApprovalMsg(EverHash).
+// Tx.Nonce is fixed at propose time, the proposal is not executed after ExpiredAt to bound the nonce age
+type Proposal struct {
+	EverHash  string            `json:"everHash"`
+	TokenTag  string            `json:"tokenTag"`
+	Tx        Transaction       `json:"tx"`
+	Approvals map[string]string `json:"approvals"`
+	Status    string            `json:"status"`
+	Error     string            `json:"error,omitempty"` // last execute error
+	CreatedAt int64             `json:"createdAt"`       // unix second
+	ExpiredAt int64             `json:"expiredAt"`       // unix second
+}
+
+// ApprovalMsg the msg signed by approvers
+func ApprovalMsg(everHash string) string {
+	return "everPay approve tx: " + everHash
+}
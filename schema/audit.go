@@ -0,0 +1,35 @@
+package schema
+
+const (
+	AuditEventPolicy  = "policy"  // policy decision
+	AuditEventSign    = "sign"    // tx signed
+	AuditEventSubmit  = "submit"  // tx submitted to everPay
+	AuditEventConfirm = "confirm" // tx status queried from everPay after submit
+)
+
+// AuditRecord one event of sdk signing, records are hash chained:
+// Hash = sha256(PrevHash + json(record without Hash))
+type AuditRecord struct {
+	Seq      int64  `json:"seq"`
+	Time     int64  `json:"time"` // unix ms
+	Event    string `json:"event"`
+	Signer   string `json:"signer"`
+	TokenTag string `json:"tokenTag"`
+	Action   string `json:"action"`
+	To       string `json:"to"`
+	Amount   string `json:"amount"`
+	Fee      string `json:"fee"`
+	Nonce    string `json:"nonce"`
+
+	Decision string `json:"decision,omitempty"` // policy decision
+	Rule     string `json:"rule,omitempty"`     // violated policy rule
+	Reason   string `json:"reason,omitempty"`
+
+	Preimage string `json:"preimage,omitempty"` // signed msg, Transaction.String() or Bundle.String()
+	EverHash string `json:"everHash,omitempty"`
+	Error    string `json:"error,omitempty"`  // submit error
+	Status   string `json:"status,omitempty"` // everPay tx status
+
+	PrevHash string `json:"prevHash"`
+	Hash     string `json:"hash"`
+}
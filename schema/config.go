@@ -1,8 +1,23 @@
 package schema
 
+import "time"
+
 type TokenFee struct {
 	TokenTag    string            `json:"tokenTag"` // token tag
 	TransferFee string            `json:"transferFee"`
 	BundleFee   string            `json:"bundleFee"`
 	BurnFeeMap  map[string]string `json:"burnFeeMap"` // key: targetChainType, val: burnFee
 }
+
+// FeeQuote the fee of action on token, pin it into tx to avoid fee change between quote and submit
+type FeeQuote struct {
+	TokenTag        string
+	Action          string
+	TargetChainType string // burn only
+	Fee             string
+	ExpiresAt       time.Time
+}
+
+func (q FeeQuote) Expired() bool {
+	return time.Now().After(q.ExpiresAt)
+}
@@ -3,13 +3,48 @@ package schema
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 )
 
 var (
 	ERR_LARGER_DATA = errors.New("err_larger_data")
 
 	ERR_TOKEN_NOT_EXIST    = errors.New("err_not_exist_token")
+	ERR_TOKEN_AMBIGUOUS    = errors.New("err_ambiguous_token")
 	ERR_BURN_FEE_NOT_EXIST = errors.New("err_not_exist_burn_fee")
+	ERR_FEE_QUOTE_EXPIRED  = errors.New("err_fee_quote_expired")
+	ERR_FEE_QUOTE_MISMATCH = errors.New("err_fee_quote_mismatch")
+
+	ERR_INSUFFICIENT_BALANCE = errors.New("err_insufficient_balance")
+
+	ERR_INVALID_PAYMENT_REQUEST = errors.New("err_invalid_payment_request")
+	ERR_PAYMENT_REQUEST_EXPIRED = errors.New("err_payment_request_expired")
+	ERR_CALLBACK_NOT_ALLOWED    = errors.New("err_callback_not_allowed")
+	ERR_INVOICE_NOT_EXIST       = errors.New("err_invoice_not_exist")
+
+	ERR_INVALID_API_KEY     = errors.New("err_invalid_api_key")
+	ERR_SPEND_LIMIT_REACHED = errors.New("err_spend_limit_reached")
+	ERR_TX_NOT_FOUND        = errors.New("err_tx_not_found")
+
+	ERR_AUDIT_TAMPERED = errors.New("err_audit_tampered")
+
+	ERR_SIGN_MSG_NOT_SUPPORT = errors.New("err_sign_msg_not_support")
+	ERR_INVALID_AUTH_TOKEN   = errors.New("err_invalid_auth_token")
+	ERR_SIGN_HASH_MISMATCH   = errors.New("err_sign_hash_mismatch")
+
+	ERR_INVALID_THRESHOLD      = errors.New("err_invalid_threshold")
+	ERR_NOT_APPROVER           = errors.New("err_not_approver")
+	ERR_PROPOSAL_NOT_EXIST     = errors.New("err_proposal_not_exist")
+	ERR_PROPOSAL_NOT_PENDING   = errors.New("err_proposal_not_pending")
+	ERR_PROPOSAL_EXPIRED       = errors.New("err_proposal_expired")
+	ERR_PROPOSAL_NOT_ENOUGH    = errors.New("err_proposal_not_enough_approvals")
+	ERR_PROPOSAL_HASH_MISMATCH = errors.New("err_proposal_hash_mismatch")
+
+	ERR_NOT_TNS102_TOKEN        = errors.New("err_not_tns102_token")
+	ERR_INVALID_OWNER_PROOF     = errors.New("err_invalid_owner_proof")
+	ERR_OWNER_CHALLENGE_EXPIRED = errors.New("err_owner_challenge_expired")
+	ERR_OWNER_CHALLENGE_USED    = errors.New("err_owner_challenge_used")
+	ERR_OWNER_NOT_CONFIRMED     = errors.New("err_owner_not_confirmed")
 
 	ERR_NOT_BUNDLE_TX = errors.New("err_not_bundle_tx")
 	ERR_NOT_JSON_DATA = errors.New("err_not_json_data")
@@ -17,10 +52,13 @@ var (
 	ERR_EMAIL_CODE_EXPIRED   = errors.New("err_email_code_expired")
 	ERR_REGISTER_SIG         = errors.New("err_register_sig")
 	ERR_RP_ID_NOT_EXIST      = errors.New("err_rp_id_not_exist")
+	ERR_INVALID_RP_ID        = errors.New("err_invalid_rp_id")
+	ERR_INVALID_RP_ORIGIN    = errors.New("err_invalid_rp_origin")
 	ERR_ACC_TYPE_NOT_SUPPORT = errors.New("err_acc_type_not_support")
 	ERR_SIGNER_INCORRECT     = errors.New("err_signer_incorrect")
 
 	ERR_INVALID_ID                = errors.New("err_invalid_id")
+	ERR_INVALID_TAG               = errors.New("err_invalid_tag")
 	ERR_INVALID_OWNER             = errors.New("err_invalid_owner")
 	ERR_INVALID_TX_VERSION        = errors.New("err_invalid_tx_version")
 	ERR_INVALID_AMOUNT            = errors.New("err_invalid_amount")
@@ -30,6 +68,14 @@ var (
 	ERR_INVALID_BUNDLE_DATA       = errors.New("err_invalid_bundle_data")
 	ERR_INVALID_ACCOUNT_TYPE      = errors.New("err_invalid_account_type")
 	ERR_INVALID_SIGNATURE         = errors.New("err_invalid_signature")
+	ERR_INVALID_EMAIL             = errors.New("err_invalid_email")
+	ERR_INVALID_EVER_HASH         = errors.New("err_invalid_ever_hash")
+	ERR_INVALID_PUBLIC            = errors.New("err_invalid_public")
+	ERR_INVALID_PUBLIC_TYPE       = errors.New("err_invalid_public_type")
+
+	ERR_PUBLIC_NOT_EXIST   = errors.New("err_public_not_exist")
+	ERR_PUBLIC_EXIST       = errors.New("err_public_exist")
+	ERR_REMOVE_LAST_PUBLIC = errors.New("err_remove_last_public")
 
 	ERR_NOT_FOUND_BUNDLE_SIG   = errors.New("err_not_found_bundle_sig")
 	ERR_NOT_FOUND_BUNDLE_ITEMS = errors.New("err_not_found_bundle_items")
@@ -56,3 +102,15 @@ func (e InternalErr) Error() string {
 	jsErr, _ := json.Marshal(&e)
 	return string(jsErr)
 }
+
+// PolicyViolation returned when tx denied by spending policy
+type PolicyViolation struct {
+	Rule     string `json:"rule"`
+	Action   string `json:"action"`
+	TokenTag string `json:"tokenTag"`
+	Msg      string `json:"msg"`
+}
+
+func (e PolicyViolation) Error() string {
+	return fmt.Sprintf("policy violation: %s, %s", e.Rule, e.Msg)
+}
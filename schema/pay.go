@@ -13,6 +13,12 @@ const (
 	TxActionBundle    = "bundle"
 	TxActionSet       = "set"
 	TxActionRegister  = "register"
+
+	MaxTxDataLength = 30000 // max length of Transaction.Data
+
+	// set tx data operate
+	SetOperateAddPublic    = "addPublic"
+	SetOperateRemovePublic = "removePublic"
 )
 
 type Transaction struct {
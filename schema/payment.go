@@ -0,0 +1,41 @@
+package schema
+
+const (
+	// PaymentURIScheme everpay:<recipient>?tag=<tokenTag>&amount=<amount>&memo=<memo>&exp=<expiration>&callback=<url>
+	PaymentURIScheme = "everpay"
+)
+
+// PaymentRequest merchant payment request, encoded as URI for link or QR code
+type PaymentRequest struct {
+	Recipient  string // accId or email
+	TokenTag   string
+	Amount     string // decimal amount, e.g. "1.5"
+	Memo       string // embedded in transfer tx data as "memo"
+	Expiration int64  // unix second, 0 means never expired
+	Callback   string // option, https url, payer may notify the everHash to callback
+}
+
+// PaymentNotify payer POST to PaymentRequest.Callback after transfer submitted
+type PaymentNotify struct {
+	EverHash string `json:"everHash"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	TokenTag string `json:"tokenTag"`
+	Amount   string `json:"amount"` // integer amount
+	Memo     string `json:"memo"`
+}
+
+const (
+	InvoiceStatusPending   = "pending"
+	InvoiceStatusPaid      = "paid"
+	InvoiceStatusUnderpaid = "underpaid"
+	InvoiceStatusOverpaid  = "overpaid"
+	InvoiceStatusExpired   = "expired"
+)
+
+// InvoiceEvent Type is the invoice status after the change
+type InvoiceEvent struct {
+	Type    string
+	Invoice Invoice
+	Tx      *TxResponse // nil when invoice expired without payment
+}
@@ -0,0 +1,37 @@
+package schema
+
+// Policy spending policy evaluated before sdk signing, amounts are integer strings
+type Policy struct {
+	ForbiddenActions  []string               `json:"forbiddenActions" yaml:"forbiddenActions"`
+	AllowedRecipients []string               `json:"allowedRecipients" yaml:"allowedRecipients"` // empty means allow all
+	Tokens            map[string]TokenPolicy `json:"tokens" yaml:"tokens"`                       // key: tokenTag
+	TimeWindows       []TimeWindow           `json:"timeWindows" yaml:"timeWindows"`             // empty means allow all the time
+	Timezone          string                 `json:"timezone" yaml:"timezone"`                   // IANA name, default UTC
+}
+
+type TokenPolicy struct {
+	MaxAmount      string `json:"maxAmount" yaml:"maxAmount"`           // max amount + fee of single tx
+	DailyLimit     string `json:"dailyLimit" yaml:"dailyLimit"`         // max amount + fee in last 24h
+	VelocityCount  int    `json:"velocityCount" yaml:"velocityCount"`   // max tx num in VelocityWindow
+	VelocityWindow string `json:"velocityWindow" yaml:"velocityWindow"` // duration, e.g. "1h"
+}
+
+// TimeWindow Start and End format "15:04", End earlier than Start means overnight
+type TimeWindow struct {
+	Start    string   `json:"start" yaml:"start"`
+	End      string   `json:"end" yaml:"end"`
+	Weekdays []string `json:"weekdays" yaml:"weekdays"` // e.g. "Mon", empty means everyday
+}
+
+const (
+	PolicyRuleForbiddenAction = "forbidden_action"
+	PolicyRuleRecipient       = "recipient"
+	PolicyRuleMaxAmount       = "max_amount"
+	PolicyRuleDailyLimit      = "daily_limit"
+	PolicyRuleVelocity        = "velocity"
+	PolicyRuleTimeWindow      = "time_window"
+	PolicyRuleToken           = "token" // token not in policy, used by remote signer
+
+	PolicyDecisionAllow = "allow"
+	PolicyDecisionDeny  = "deny"
+)
@@ -0,0 +1,33 @@
+package schema
+
+// remote signer http api:
+// GET  /signer       -> RemoteSignerInfo
+// POST /sign/tx      RemoteSignTxReq -> RemoteSignResp
+// POST /sign/bundle  RemoteSignBundleReq -> RemoteSignResp
+// all requests need header "Authorization: Bearer <token>", errors are RespPolicyErr
+
+type RemoteSignerInfo struct {
+	Address    string `json:"address"`
+	SignerType string `json:"signerType"`
+}
+
+// RemoteSignTxReq Tx without sig, signer re-derive the preimage by Tx.String()
+type RemoteSignTxReq struct {
+	Tx Transaction `json:"tx"`
+}
+
+type RemoteSignBundleReq struct {
+	Bundle Bundle `json:"bundle"`
+}
+
+// RemoteSignResp Hash: everHash of tx or hash of bundle, client should check it equal to local hash
+type RemoteSignResp struct {
+	Sig  string `json:"sig"`
+	Hash string `json:"hash"`
+}
+
+// RespPolicyErr RespErr with policy violation detail
+type RespPolicyErr struct {
+	Err       string           `json:"error"`
+	Violation *PolicyViolation `json:"violation,omitempty"`
+}
@@ -21,3 +21,16 @@ type ChEverTx struct {
 	Sig            string `json:"sig"`
 	InternalStatus string `json:"internalStatus"` // if internal tx (bundle tx) execute success, return "success" then return err info
 }
+
+type Invoice struct {
+	ID         string `gorm:"primaryKey;type:varchar(64)" json:"id"` // unique reference, embedded in transfer tx data as "memo"
+	AccId      string `gorm:"index:idx_acc" json:"accid"`            // merchant receiver
+	TokenTag   string `json:"tokenTag"`
+	Amount     string `json:"amount"` // integer amount
+	Paid       string `json:"paid"`   // total paid amount
+	Status     string `gorm:"index:idx_status" json:"status"`
+	EverHashes string `json:"everHashes"` // paid everHashes, split by ","
+	CreatedAt  int64  `json:"createdAt"`  // unix second
+	ExpiredAt  int64  `json:"expiredAt"`  // unix second
+	UpdatedAt  int64  `json:"updatedAt"`
+}
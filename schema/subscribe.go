@@ -1,9 +1,40 @@
 package schema
 
+import "math/big"
+
 type FilterQuery struct {
 	StartCursor   int64
 	Address       string
 	TokenTag      string
 	Action        string
 	WithoutAction string
+
+	// client side filters, see TxOpts
+	From      string
+	To        string
+	Actions   []string
+	TokenTags []string
+	Status    string
+	StartTime int64
+	EndTime   int64
+	MinAmount *big.Int
+	MaxAmount *big.Int
+}
+
+func (f FilterQuery) TxOpts() TxOpts {
+	return TxOpts{
+		Address:       f.Address,
+		TokenTag:      f.TokenTag,
+		Action:        f.Action,
+		WithoutAction: f.WithoutAction,
+		From:          f.From,
+		To:            f.To,
+		Actions:       f.Actions,
+		TokenTags:     f.TokenTags,
+		Status:        f.Status,
+		StartTime:     f.StartTime,
+		EndTime:       f.EndTime,
+		MinAmount:     f.MinAmount,
+		MaxAmount:     f.MaxAmount,
+	}
 }
@@ -76,6 +76,20 @@ type TargetChain struct {
 }
 
 func tag(chainType, tokenSymbol, tokenID string) string {
+	id, err := FormatTokenID(chainType, tokenID)
+	if err != nil {
+		return "err_invalid_token"
+	}
+	return strings.ToLower(chainType+"-"+tokenSymbol) + "-" + id
+}
+
+// GenTag return the token tag: lower(chainType-symbol)-id
+func GenTag(chainType, tokenSymbol, tokenID string) string {
+	return tag(chainType, tokenSymbol, tokenID)
+}
+
+// FormatTokenID format tokenID as it in tag
+func FormatTokenID(chainType, tokenID string) (string, error) {
 	// process tokenId
 	var id string
 	switch chainType {
@@ -84,7 +98,7 @@ func tag(chainType, tokenSymbol, tokenID string) string {
 	case ChainTypeCrossArEth: // now only AR token
 		ids := strings.Split(tokenID, ",")
 		if len(ids) != 2 {
-			return "err_invalid_token"
+			return "", ERR_INVALID_TAG
 		}
 
 		ids[1] = strings.ToLower(ids[1])
@@ -92,6 +106,22 @@ func tag(chainType, tokenSymbol, tokenID string) string {
 	default: // "ethereum", "avalanche" and so on evm chain
 		id = strings.ToLower(tokenID)
 	}
+	return id, nil
+}
 
-	return strings.ToLower(chainType+"-"+tokenSymbol) + "-" + id
+// ParseTag parse tag to chainType, symbol(lower case) and tokenID
+// e.g. "arweave,ethereum-ar-AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA,0xcc9141efa8c20c7df0778748255b1487957811be"
+func ParseTag(tokenTag string) (chainType, symbol, tokenID string, err error) {
+	// arweave tokenID may contain "-"
+	ss := strings.SplitN(tokenTag, "-", 3)
+	if len(ss) != 3 || ss[0] == "" || ss[1] == "" || ss[2] == "" {
+		err = ERR_INVALID_TAG
+		return
+	}
+	chainType, symbol, tokenID = ss[0], ss[1], ss[2]
+	if chainType == ChainTypeCrossArEth && len(strings.Split(tokenID, ",")) != 2 {
+		err = ERR_INVALID_TAG
+		return
+	}
+	return
 }
@@ -0,0 +1,75 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenState desired admin state of TNS-102 token, nil or empty fields are not managed.
+// OwnerChallenge and OwnerProof (signed by Owner) are required to change Owner
+type TokenState struct {
+	WhiteList      []string        `json:"whiteList" yaml:"whiteList"`
+	BlackList      []string        `json:"blackList" yaml:"blackList"`
+	PauseWhiteList *bool           `json:"pauseWhiteList" yaml:"pauseWhiteList"`
+	PauseBlackList *bool           `json:"pauseBlackList" yaml:"pauseBlackList"`
+	Pause          *bool           `json:"pause" yaml:"pause"`
+	Owner          string          `json:"owner" yaml:"owner"`
+	OwnerChallenge *OwnerChallenge `json:"ownerChallenge" yaml:"ownerChallenge"`
+	OwnerProof     string          `json:"ownerProof" yaml:"ownerProof"`
+}
+
+// AdminStep one admin tx of plan, List: add/remove list; Pause: pause txs;
+// Owner, Challenge and Proof: transferOwner tx with the proof of control of new owner
+type AdminStep struct {
+	TokenTag  string          `json:"tokenTag"`
+	Action    string          `json:"action"`
+	List      []string        `json:"list,omitempty"`
+	Pause     bool            `json:"pause,omitempty"`
+	Owner     string          `json:"owner,omitempty"`
+	Challenge *OwnerChallenge `json:"challenge,omitempty"`
+	Proof     string          `json:"proof,omitempty"`
+}
+
+func (s AdminStep) String() string {
+	switch s.Action {
+	case TxActionAddWhiteList, TxActionRemoveWhiteList, TxActionAddBlackList, TxActionRemoveBlackList:
+		return fmt.Sprintf("%s %s [%s]", s.TokenTag, s.Action, strings.Join(s.List, ", "))
+	case TxActionPauseWhiteList, TxActionPauseBlackList, TxActionPause:
+		return fmt.Sprintf("%s %s %v", s.TokenTag, s.Action, s.Pause)
+	default:
+		return fmt.Sprintf("%s %s %s", s.TokenTag, s.Action, s.Owner)
+	}
+}
+
+// AdminPlan the admin txs to reach desired state, empty Steps means no change
+type AdminPlan struct {
+	Steps []AdminStep `json:"steps"`
+}
+
+// AdminStepResult EverHashes: list steps may be split into several txs
+type AdminStepResult struct {
+	Step       AdminStep `json:"step"`
+	EverHashes []string  `json:"everHashes"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// ListChunkResult one tx of chunked white list or black list update, Err is nil if submitted
+type ListChunkResult struct {
+	List []string
+	Tx   *Transaction
+	Err  error
+}
+
+// OwnerChallenge signed by new owner to prove the control of newOwner before token owner transfer
+type OwnerChallenge struct {
+	TokenTag   string `json:"tokenTag" yaml:"tokenTag"`
+	Owner      string `json:"owner" yaml:"owner"`
+	NewOwner   string `json:"newOwner" yaml:"newOwner"`
+	Expiration int64  `json:"expiration" yaml:"expiration"` // unix second
+	Salt       string `json:"salt" yaml:"salt"`
+}
+
+func (c OwnerChallenge) String() string {
+	return fmt.Sprintf("everPay token owner transfer\ntokenTag:%s\nowner:%s\nnewOwner:%s\nexpiration:%d\nsalt:%s",
+		c.TokenTag, c.Owner, c.NewOwner, c.Expiration, c.Salt)
+}
@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"math/big"
+
+	"github.com/everFinance/ethrpc"
+	arTypes "github.com/everFinance/goar/types"
+)
+
+// MintTxData decoded data of mint tx
+type MintTxData struct {
+	TargetChainType   string
+	TargetChainTxHash string // the origin tx hash on target chain, everHash when targetChainType is everpay
+
+	EthTx   *ethrpc.Transaction // ethereum, moon, conflux, bsc, platon
+	ArTx    *arTypes.Transaction
+	AosItem *arTypes.BundleItem // aos mainItem
+}
+
+// BurnTxData decoded data of burn tx
+type BurnTxData struct {
+	TargetChainType string `json:"targetChainType"`
+}
+
+type WhiteListTxData struct {
+	WhiteList []string `json:"whiteList"`
+}
+
+type BlackListTxData struct {
+	BlackList []string `json:"blackList"`
+}
+
+// PauseTxData decoded data of pauseWhiteList, pauseBlackList and pause tx
+type PauseTxData struct {
+	Pause bool `json:"pause"`
+}
+
+// RawTxData data of transfer tx and other tx without fixed data format
+type RawTxData struct {
+	Data string
+}
+
+// Deposit normalized mint tx of an everPay account
+type Deposit struct {
+	RawId     int64
+	EverHash  string
+	AccId     string // receiver
+	TokenTag  string
+	Symbol    string
+	Timestamp int64
+
+	TargetChainType   string
+	TargetChainTxHash string // the origin tx hash on target chain
+
+	Amount         *big.Int // everPay amount
+	Decimals       int      // everPay decimals
+	TargetAmount   *big.Int // amount on target chain
+	TargetDecimals int      // target chain decimals
+}
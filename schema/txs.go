@@ -1,5 +1,7 @@
 package schema
 
+import "strconv"
+
 const (
 	TxStatusConfirmed = "confirmed"
 	TxStatusPackaged  = "packaged"
@@ -33,3 +35,30 @@ type TxResponse struct {
 	// for cross chain
 	TargetChainTxHash string `json:"targetChainTxHash"`
 }
+
+// Tag is the unique identifier of token
+func (t *TxResponse) Tag() string {
+	return tag(t.ChainType, t.TokenSymbol, t.TokenID)
+}
+
+// Transaction reconstruct the signed everTx from TxResponse
+func (t *TxResponse) Transaction() Transaction {
+	return Transaction{
+		TokenSymbol:  t.TokenSymbol,
+		Action:       t.Action,
+		From:         t.From,
+		To:           t.To,
+		Amount:       t.Amount,
+		Fee:          t.Fee,
+		FeeRecipient: t.FeeRecipient,
+		Nonce:        strconv.FormatInt(t.Nonce, 10),
+		TokenID:      t.TokenID,
+		ChainType:    t.ChainType,
+		ChainID:      t.ChainID,
+		Data:         t.Data,
+		Version:      t.Version,
+		Sig:          t.Sig,
+		ArTxID:       t.ID,
+		ArTimestamp:  t.Timestamp,
+	}
+}
@@ -0,0 +1,25 @@
+package schema
+
+const (
+	WebhookSignatureHeader = "X-Everpay-Signature" // hex(hmac_sha256(secret, timestamp + "." + body))
+	WebhookTimestampHeader = "X-Everpay-Timestamp" // unix second
+)
+
+// WebhookEvent the json body of webhook
+type WebhookEvent struct {
+	RawId    int64      `json:"rawId"`
+	EverHash string     `json:"everHash"`
+	Tx       TxResponse `json:"tx"`
+}
+
+// DeadLetter webhook failed after all retries, Attempts and Error are updated by failed replays
+type DeadLetter struct {
+	URL       string `json:"url"`
+	RawId     int64  `json:"rawId"`
+	EverHash  string `json:"everHash"`
+	Payload   string `json:"payload"`
+	Attempts  int    `json:"attempts"`
+	Error     string `json:"error"`
+	CreatedAt int64  `json:"createdAt"` // unix second
+	UpdatedAt int64  `json:"updatedAt"` // unix second, last failed replay
+}
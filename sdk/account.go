@@ -0,0 +1,174 @@
+package sdk
+
+import (
+	"encoding/json"
+	"math/big"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+)
+
+// GenRegisterData assemble register tx data for eid account
+// public: base64 encoded public; publicType: ECDSA, RSA or FIDO2
+func GenRegisterData(verify schema.MailVerify, public, publicType string) (string, error) {
+	data := schema.RegisterData{
+		MailVerify: verify,
+		Public:     public,
+		PublicType: publicType,
+	}
+	by, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+	return string(by), nil
+}
+
+func (s *SDK) everIdSigner() (*EverIdSigner, error) {
+	if s.signerType != EverIdSignerType {
+		return nil, schema.ERR_ACC_TYPE_NOT_SUPPORT
+	}
+	return s.signer.(*EverIdSigner), nil
+}
+
+// SendEmailCode request everPay to send a verification code to the email of EverIdSigner
+func (s *SDK) SendEmailCode() error {
+	signer, err := s.everIdSigner()
+	if err != nil {
+		return err
+	}
+	return s.Cli.SendEmailCode(signer.Email)
+}
+
+// VerifyEmailCode verify the code received by email, return the mailVerify used by Register
+func (s *SDK) VerifyEmailCode(code string) (schema.MailVerify, error) {
+	signer, err := s.everIdSigner()
+	if err != nil {
+		return schema.MailVerify{}, err
+	}
+	resp, err := s.Cli.VerifyEmailCode(signer.Email, code)
+	if err != nil {
+		return schema.MailVerify{}, err
+	}
+	return schema.MailVerify{Timestamp: resp.Timestamp, Code: code, Sig: resp.Sig}, nil
+}
+
+// Register register eid account with the signer public key
+// verify: the email code of signer and its sig issued by everPay owner, returned by VerifyEmailCode
+// register tx must be submitted in 5 minutes after email code verified
+func (s *SDK) Register(tokenTag string, verify schema.MailVerify) (*schema.Transaction, error) {
+	signer, err := s.everIdSigner()
+	if err != nil {
+		return nil, err
+	}
+	tokenInfo, ok := s.tokens[tokenTag]
+	if !ok {
+		return nil, schema.ERR_TOKEN_NOT_EXIST
+	}
+
+	if err = utils.VerifyMailVerifySig(verify.Timestamp, verify.Code, verify.Sig, signer.EverId, s.Info.Owner); err != nil {
+		log.Error("invalid mail verify sig", "err", err)
+		return nil, schema.ERR_REGISTER_SIG
+	}
+	if d := time.Now().Unix() - verify.Timestamp; d < 0 || d > schema.MailVerifyExpiration {
+		return nil, schema.ERR_EMAIL_CODE_EXPIRED
+	}
+
+	data, err := GenRegisterData(verify, signer.Public(), signer.PublicType())
+	if err != nil {
+		return nil, err
+	}
+	return s.sendTx(tokenInfo, schema.TxActionRegister, "0", signer.EverId, big.NewInt(0), data)
+}
+
+// AccInfo get the registered account info of sdk AccId
+func (s *SDK) AccInfo() (schema.RespAcc, error) {
+	return s.Cli.AccInfo(s.AccId)
+}
+
+// PublicKeys list the public keys of sdk eid account
+func (s *SDK) PublicKeys() ([]schema.AccPublic, error) {
+	acc, err := s.AccInfo()
+	if err != nil {
+		return nil, err
+	}
+	return acc.Publics(), nil
+}
+
+// AddPublicKey add a new public key to eid account, the tx must be signed by an authorized key
+// publicType: ECDSA, RSA or FIDO2; public: base64 encoded public, FIDO2 public is the json of webauthn.Credential
+func (s *SDK) AddPublicKey(tokenTag, publicType, public string) (*schema.Transaction, error) {
+	if err := utils.CheckPublic(publicType, public); err != nil {
+		return nil, err
+	}
+	acc, err := s.authorizedAccInfo()
+	if err != nil {
+		return nil, err
+	}
+	for _, pub := range acc.Publics() {
+		if pub.Value == public {
+			return nil, schema.ERR_PUBLIC_EXIST
+		}
+	}
+
+	return s.sendSetPublicTx(tokenTag, schema.SetPublicData{
+		Operate:    schema.SetOperateAddPublic,
+		PublicType: publicType,
+		Public:     public,
+	})
+}
+
+// RemovePublicKey remove a public key from eid account by publicId, the last public key can not be removed
+func (s *SDK) RemovePublicKey(tokenTag, publicId string) (*schema.Transaction, error) {
+	acc, err := s.authorizedAccInfo()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := acc.PublicValues[publicId]; !ok {
+		return nil, schema.ERR_PUBLIC_NOT_EXIST
+	}
+	if len(acc.PublicValues) <= 1 {
+		return nil, schema.ERR_REMOVE_LAST_PUBLIC
+	}
+
+	return s.sendSetPublicTx(tokenTag, schema.SetPublicData{
+		Operate:  schema.SetOperateRemovePublic,
+		PublicId: publicId,
+	})
+}
+
+// CheckSignerKey check the public of EverIdSigner is one of the sdk eid account public keys
+func (s *SDK) CheckSignerKey() error {
+	_, err := s.authorizedAccInfo()
+	return err
+}
+
+// authorizedAccInfo get account info and check the signer public is one of the account public keys
+func (s *SDK) authorizedAccInfo() (schema.RespAcc, error) {
+	signer, err := s.everIdSigner()
+	if err != nil {
+		return schema.RespAcc{}, err
+	}
+	acc, err := s.AccInfo()
+	if err != nil {
+		return schema.RespAcc{}, err
+	}
+	for _, pub := range acc.Publics() {
+		if pub.Type == signer.PublicType() && pub.Value == signer.Public() {
+			return acc, nil
+		}
+	}
+	return schema.RespAcc{}, schema.ERR_SIGNER_INCORRECT
+}
+
+func (s *SDK) sendSetPublicTx(tokenTag string, setData schema.SetPublicData) (*schema.Transaction, error) {
+	tokenInfo, ok := s.tokens[tokenTag]
+	if !ok {
+		return nil, schema.ERR_TOKEN_NOT_EXIST
+	}
+	data, err := json.Marshal(setData)
+	if err != nil {
+		return nil, err
+	}
+	return s.sendTx(tokenInfo, schema.TxActionSet, "0", s.AccId, big.NewInt(0), string(data))
+}
@@ -0,0 +1,192 @@
+package sdk
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/everFinance/goar"
+	arUtils "github.com/everFinance/goar/utils"
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func testMailVerify(t *testing.T, owner *goar.Signer, timestamp int64, code, everId string) schema.MailVerify {
+	sig, err := owner.SignMsg(accounts.TextHash([]byte(fmt.Sprintf("%d", timestamp) + code + everId)))
+	assert.NoError(t, err)
+	return schema.MailVerify{Timestamp: timestamp, Code: code, Sig: arUtils.Base64Encode(sig) + "," + owner.Owner()}
+}
+
+func TestSDK_Register(t *testing.T) {
+	prv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	owner := goar.NewSignerByPrivateKey(prv)
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.payOwner = owner.Address
+
+	eid, err := NewEverIdSigner("test@everpay.io", newTestEccSigner(t))
+	assert.NoError(t, err)
+	s, err := New(eid, srv.URL)
+	assert.NoError(t, err)
+
+	now := time.Now().Unix()
+	_, err = srv.newSDK(t).Register(testTokenTag, testMailVerify(t, owner, now, "123456", eid.EverId))
+	assert.Equal(t, schema.ERR_ACC_TYPE_NOT_SUPPORT, err)
+	// code of other eid
+	_, err = s.Register(testTokenTag, testMailVerify(t, owner, now, "123456", utils.GenEverId("other@everpay.io")))
+	assert.Equal(t, schema.ERR_REGISTER_SIG, err)
+	_, err = s.Register(testTokenTag, testMailVerify(t, owner, now-schema.MailVerifyExpiration-1, "123456", eid.EverId))
+	assert.Equal(t, schema.ERR_EMAIL_CODE_EXPIRED, err)
+	_, err = s.AccInfo()
+	assert.Error(t, err)
+	assert.Equal(t, 0, len(srv.submitted()))
+
+	tx, err := s.Register(testTokenTag, testMailVerify(t, owner, now, "123456", eid.EverId))
+	assert.NoError(t, err)
+	assert.Equal(t, schema.TxActionRegister, tx.Action)
+	assert.Equal(t, eid.EverId, tx.From)
+	// ArOwner is filled by everPay server
+	registerTx := *tx
+	registerTx.ArOwner = owner.Address
+	_, _, err = utils.VerifyTransaction(registerTx, owner.Address, 1)
+	assert.NoError(t, err)
+
+	acc, err := s.AccInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.AccPublic{{Id: "0", Type: schema.EVMPublicType, Value: eid.Public()}}, acc.Publics())
+}
+
+func TestSDK_VerifyEmailCode(t *testing.T) {
+	prv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	owner := goar.NewSignerByPrivateKey(prv)
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.payOwner = owner.Address
+	srv.mailSig = func(timestamp int64, code, email string) string {
+		return testMailVerify(t, owner, timestamp, code, utils.GenEverId(email)).Sig
+	}
+
+	eid, err := NewEverIdSigner("test@everpay.io", newTestEccSigner(t))
+	assert.NoError(t, err)
+	s, err := New(eid, srv.URL)
+	assert.NoError(t, err)
+
+	assert.Equal(t, schema.ERR_ACC_TYPE_NOT_SUPPORT, srv.newSDK(t).SendEmailCode())
+	// code not sent
+	_, err = s.VerifyEmailCode("123456")
+	assert.Equal(t, "err_invalid_email_code", err.Error())
+
+	assert.NoError(t, s.SendEmailCode())
+	_, err = s.VerifyEmailCode("654321")
+	assert.Equal(t, "err_invalid_email_code", err.Error())
+	verify, err := s.VerifyEmailCode("123456")
+	assert.NoError(t, err)
+	assert.Equal(t, "123456", verify.Code)
+
+	tx, err := s.Register(testTokenTag, verify)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.TxActionRegister, tx.Action)
+	_, err = s.AccInfo()
+	assert.NoError(t, err)
+}
+
+func TestSDK_CheckSignerKey(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	eid, err := NewEverIdSigner("test@everpay.io", newTestEccSigner(t))
+	assert.NoError(t, err)
+	s, err := New(eid, srv.URL)
+	assert.NoError(t, err)
+
+	assert.Equal(t, schema.ERR_ACC_TYPE_NOT_SUPPORT, srv.newSDK(t).CheckSignerKey())
+	// not registered
+	assert.Error(t, s.CheckSignerKey())
+
+	srv.update(func(p *testPayServer) {
+		p.accs[eid.EverId] = schema.RespAcc{
+			Id:           eid.EverId,
+			Type:         schema.AccountTypeEverId,
+			PublicType:   map[string]string{"1": schema.EVMPublicType, "0": schema.ArPublicType},
+			PublicValues: map[string]string{"1": eid.Public(), "0": "rsa-public"},
+		}
+	})
+	pubs, err := s.PublicKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.AccPublic{
+		{Id: "0", Type: schema.ArPublicType, Value: "rsa-public"},
+		{Id: "1", Type: schema.EVMPublicType, Value: eid.Public()},
+	}, pubs)
+	assert.NoError(t, s.CheckSignerKey())
+
+	// other key of the same email
+	other, err := NewEverIdSigner("test@everpay.io", newTestEccSigner(t))
+	assert.NoError(t, err)
+	s, err = New(other, srv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.ERR_SIGNER_INCORRECT, s.CheckSignerKey())
+}
+
+func TestSDK_AddRemovePublicKey(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	eid, err := NewEverIdSigner("test@everpay.io", newTestEccSigner(t))
+	assert.NoError(t, err)
+	s, err := New(eid, srv.URL)
+	assert.NoError(t, err)
+	newKey, err := NewEverIdSigner("test@everpay.io", newTestEccSigner(t))
+	assert.NoError(t, err)
+
+	// not registered
+	_, err = s.AddPublicKey(testTokenTag, schema.EVMPublicType, newKey.Public())
+	assert.Error(t, err)
+	srv.update(func(p *testPayServer) {
+		p.accs[eid.EverId] = schema.RespAcc{
+			Id:           eid.EverId,
+			Type:         schema.AccountTypeEverId,
+			PublicType:   map[string]string{"0": schema.EVMPublicType},
+			PublicValues: map[string]string{"0": eid.Public()},
+		}
+	})
+
+	_, err = s.AddPublicKey(testTokenTag, schema.EVMPublicType, "invalid")
+	assert.Equal(t, schema.ERR_INVALID_PUBLIC, err)
+	_, err = s.AddPublicKey(testTokenTag, "unknown", newKey.Public())
+	assert.Equal(t, schema.ERR_INVALID_PUBLIC_TYPE, err)
+	_, err = s.AddPublicKey(testTokenTag, schema.EVMPublicType, eid.Public())
+	assert.Equal(t, schema.ERR_PUBLIC_EXIST, err)
+	_, err = s.RemovePublicKey(testTokenTag, "0")
+	assert.Equal(t, schema.ERR_REMOVE_LAST_PUBLIC, err)
+	// new key is not authorized
+	other, err := New(newKey, srv.URL)
+	assert.NoError(t, err)
+	_, err = other.AddPublicKey(testTokenTag, schema.EVMPublicType, newKey.Public())
+	assert.Equal(t, schema.ERR_SIGNER_INCORRECT, err)
+	assert.Equal(t, 0, len(srv.submitted()))
+
+	tx, err := s.AddPublicKey(testTokenTag, schema.EVMPublicType, newKey.Public())
+	assert.NoError(t, err)
+	assert.Equal(t, schema.TxActionSet, tx.Action)
+	assert.Equal(t, eid.EverId, tx.To)
+	pubs, err := s.PublicKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.AccPublic{
+		{Id: "0", Type: schema.EVMPublicType, Value: eid.Public()},
+		{Id: "1", Type: schema.EVMPublicType, Value: newKey.Public()},
+	}, pubs)
+	assert.NoError(t, other.CheckSignerKey())
+
+	// rotate: the new key removes the old one
+	_, err = other.RemovePublicKey(testTokenTag, "2")
+	assert.Equal(t, schema.ERR_PUBLIC_NOT_EXIST, err)
+	_, err = other.RemovePublicKey(testTokenTag, "0")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.ERR_SIGNER_INCORRECT, s.CheckSignerKey())
+	assert.NoError(t, other.CheckSignerKey())
+	assert.Equal(t, 2, len(srv.submitted()))
+}
@@ -0,0 +1,256 @@
+package sdk
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+)
+
+// DefaultProposalTTL everPay may reject the tx with old nonce, the proposal should be approved in short time
+const DefaultProposalTTL = time.Hour
+
+// ApprovalWorkflow M-of-N approval of treasury txs,
+// the tx is signed and submitted by sdk signer only after threshold approvers signed its everHash
+type ApprovalWorkflow struct {
+	sdk       *SDK
+	approvers map[string]bool // accId normalized by utils.IDCheck
+	threshold int
+	storage   ApprovalStorage
+	ttl       time.Duration
+
+	lock sync.Mutex
+}
+
+// NewApprovalWorkflow approvers: everPay accounts of approvers; storage default MemoryApprovalStorage
+func (s *SDK) NewApprovalWorkflow(approvers []string, threshold int, storage ApprovalStorage) (*ApprovalWorkflow, error) {
+	w := &ApprovalWorkflow{
+		sdk:       s,
+		approvers: make(map[string]bool),
+		threshold: threshold,
+		storage:   storage,
+		ttl:       DefaultProposalTTL,
+	}
+	for _, approver := range approvers {
+		_, accId, err := utils.IDCheck(approver)
+		if err != nil {
+			return nil, err
+		}
+		w.approvers[accId] = true
+	}
+	if threshold <= 0 || threshold > len(w.approvers) {
+		return nil, schema.ERR_INVALID_THRESHOLD
+	}
+	if w.storage == nil {
+		w.storage = NewMemoryApprovalStorage()
+	}
+	return w, nil
+}
+
+// SetProposalTTL set the valid time of new proposals
+func (w *ApprovalWorkflow) SetProposalTTL(ttl time.Duration) {
+	w.ttl = ttl
+}
+
+func (w *ApprovalWorkflow) ProposeTransfer(tokenTag string, amount *big.Int, to, data string) (schema.Proposal, error) {
+	tokenInfo, ok := w.sdk.tokens[tokenTag]
+	if !ok {
+		return schema.Proposal{}, schema.ERR_TOKEN_NOT_EXIST
+	}
+	if utils.IsEmailAddress(to) {
+		to = utils.GenEverId(to)
+	}
+	return w.propose(tokenInfo, schema.TxActionTransfer, tokenInfo.TransferFee, to, amount, data)
+}
+
+func (w *ApprovalWorkflow) ProposeWithdraw(tokenTag string, amount *big.Int, chainType, to string) (schema.Proposal, error) {
+	tokenInfo, ok := w.sdk.tokens[tokenTag]
+	if !ok {
+		return schema.Proposal{}, schema.ERR_TOKEN_NOT_EXIST
+	}
+	quote, err := w.sdk.Fees.Quote(schema.TxActionBurn, tokenTag, chainType)
+	if err != nil {
+		return schema.Proposal{}, err
+	}
+	data, err := targetChainData("", chainType)
+	if err != nil {
+		return schema.Proposal{}, err
+	}
+	return w.propose(tokenInfo, schema.TxActionBurn, quote.Fee, to, amount, data)
+}
+
+func (w *ApprovalWorkflow) propose(tokenInfo schema.TokenInfo, action, fee, to string, amount *big.Int, data string) (schema.Proposal, error) {
+	w.sdk.sendTxLocker.Lock()
+	everTx := w.sdk.assembleTx(tokenInfo, action, fee, to, amount, data)
+	w.sdk.sendTxLocker.Unlock()
+
+	now := time.Now()
+	p := schema.Proposal{
+		EverHash:  everTx.HexHash(),
+		TokenTag:  tokenInfo.Tag,
+		Tx:        everTx,
+		Approvals: make(map[string]string),
+		Status:    schema.ProposalStatusPending,
+		CreatedAt: now.Unix(),
+		ExpiredAt: now.Add(w.ttl).Unix(),
+	}
+	if err := w.storage.SaveProposal(p); err != nil {
+		return schema.Proposal{}, err
+	}
+	log.Info("tx proposed", "everHash", p.EverHash, "action", action, "tokenTag", tokenInfo.Tag, "to", to, "amount", everTx.Amount)
+	return p, nil
+}
+
+// Approve add the approval of approver, sig: approver signed schema.ApprovalMsg(everHash).
+// tx is signed and submitted when approvals reach threshold, the returned proposal status is executed or failed,
+// or still pending if the tx is neither rejected by everPay nor found on everPay (e.g. network error), which can be retried by Execute
+func (w *ApprovalWorkflow) Approve(everHash, approver, sig string) (schema.Proposal, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	p, err := w.pendingProposal(everHash)
+	if err != nil {
+		return p, err
+	}
+	// approvers sign the everHash, the stored tx must be the one they approved
+	if p.Tx.HexHash() != p.EverHash {
+		return p, schema.ERR_PROPOSAL_HASH_MISMATCH
+	}
+	_, approverId, err := utils.IDCheck(approver)
+	if err != nil || !w.approvers[approverId] {
+		return p, schema.ERR_NOT_APPROVER
+	}
+	chainID, _ := strconv.Atoi(w.sdk.Info.EthChainID)
+	if err = utils.VerifyMsg(approverId, schema.ApprovalMsg(everHash), sig, chainID); err != nil {
+		return p, err
+	}
+	p.Approvals[approverId] = sig
+	if len(p.Approvals) < w.threshold {
+		return p, w.storage.SaveProposal(p)
+	}
+	return w.execute(p)
+}
+
+// Execute retry the approved proposal which execution failed without rejected by everPay
+func (w *ApprovalWorkflow) Execute(everHash string) (schema.Proposal, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	p, err := w.pendingProposal(everHash)
+	if err != nil {
+		return p, err
+	}
+	if len(p.Approvals) < w.threshold {
+		return p, schema.ERR_PROPOSAL_NOT_ENOUGH
+	}
+	return w.execute(p)
+}
+
+// pendingProposal return the pending proposal, the expired is marked and saved
+func (w *ApprovalWorkflow) pendingProposal(everHash string) (schema.Proposal, error) {
+	p, err := w.storage.GetProposal(everHash)
+	if err != nil {
+		return p, err
+	}
+	if p.Status != schema.ProposalStatusPending {
+		return p, schema.ERR_PROPOSAL_NOT_PENDING
+	}
+	if p.ExpiredAt > 0 && time.Now().Unix() > p.ExpiredAt {
+		p.Status = schema.ProposalStatusExpired
+		if err = w.storage.SaveProposal(p); err != nil {
+			return p, err
+		}
+		return p, schema.ERR_PROPOSAL_EXPIRED
+	}
+	return p, nil
+}
+
+func (w *ApprovalWorkflow) execute(p schema.Proposal) (schema.Proposal, error) {
+	if p.Tx.HexHash() != p.EverHash {
+		return p, schema.ERR_PROPOSAL_HASH_MISMATCH
+	}
+	// the last submission may be on everPay already, do not submit it again
+	if p.Error != "" && w.landed(p.EverHash) {
+		w.recordSpend(p)
+		p.Status = schema.ProposalStatusExecuted
+		p.Error = ""
+		return p, w.storage.SaveProposal(p)
+	}
+
+	w.sdk.sendTxLocker.Lock()
+	_, err := w.sdk.signAndSubmitTx(p.TokenTag, p.Tx)
+	w.sdk.sendTxLocker.Unlock()
+	p.Status = schema.ProposalStatusExecuted
+	p.Error = ""
+	if err != nil && w.landed(p.EverHash) {
+		// e.g. response lost or duplicate submission rejected, but the tx is executed
+		log.Warn("proposal tx found after submit failed", "everHash", p.EverHash, "err", err)
+		w.recordSpend(p)
+		err = nil
+	}
+	if err != nil {
+		// only the tx rejected by everPay is final, others keep pending for retry
+		if errors.As(err, &schema.RespErr{}) {
+			p.Status = schema.ProposalStatusFailed
+		} else {
+			p.Status = schema.ProposalStatusPending
+		}
+		p.Error = err.Error()
+		log.Error("execute proposal failed", "everHash", p.EverHash, "status", p.Status, "err", err)
+	}
+	if saveErr := w.storage.SaveProposal(p); saveErr != nil {
+		return p, saveErr
+	}
+	return p, err
+}
+
+// recordSpend record the spend of tx which submission failed but found on everPay
+func (w *ApprovalWorkflow) recordSpend(p schema.Proposal) {
+	w.sdk.sendTxLocker.Lock()
+	defer w.sdk.sendTxLocker.Unlock()
+	if w.sdk.policy != nil {
+		w.sdk.policy.Record(p.TokenTag, p.Tx.Amount, p.Tx.Fee)
+	}
+}
+
+// landed whether the tx of everHash is found on everPay
+func (w *ApprovalWorkflow) landed(everHash string) bool {
+	tx, err := w.sdk.Cli.TxByHash(everHash)
+	if err != nil || tx.Tx == nil {
+		log.Debug("proposal tx not found", "everHash", everHash, "err", err)
+		return false
+	}
+	return true
+}
+
+func (w *ApprovalWorkflow) Cancel(everHash string) (schema.Proposal, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	p, err := w.storage.GetProposal(everHash)
+	if err != nil {
+		return p, err
+	}
+	if p.Status != schema.ProposalStatusPending {
+		return p, schema.ERR_PROPOSAL_NOT_PENDING
+	}
+	p.Status = schema.ProposalStatusCancelled
+	return p, w.storage.SaveProposal(p)
+}
+
+func (w *ApprovalWorkflow) Proposal(everHash string) (schema.Proposal, error) {
+	return w.storage.GetProposal(everHash)
+}
+
+func (w *ApprovalWorkflow) PendingProposals() ([]schema.Proposal, error) {
+	return w.storage.Proposals(schema.ProposalStatusPending)
+}
+
+// SignApproval sign the approval of everHash by approver signer
+func SignApproval(signer interface{}, everHash string) (string, error) {
+	return SignMsg(signer, schema.ApprovalMsg(everHash))
+}
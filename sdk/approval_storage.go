@@ -0,0 +1,70 @@
+package sdk
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/everVision/everpay-kits/schema"
+)
+
+// ApprovalStorage persist the proposals of ApprovalWorkflow
+type ApprovalStorage interface {
+	// SaveProposal insert or update proposal
+	SaveProposal(p schema.Proposal) error
+	// GetProposal return schema.ERR_PROPOSAL_NOT_EXIST if not found
+	GetProposal(everHash string) (schema.Proposal, error)
+	// Proposals return proposals of status sorted by CreatedAt, empty status means all
+	Proposals(status string) ([]schema.Proposal, error)
+}
+
+type MemoryApprovalStorage struct {
+	lock      sync.RWMutex
+	proposals map[string]schema.Proposal
+}
+
+func NewMemoryApprovalStorage() *MemoryApprovalStorage {
+	return &MemoryApprovalStorage{
+		proposals: make(map[string]schema.Proposal),
+	}
+}
+
+func (m *MemoryApprovalStorage) SaveProposal(p schema.Proposal) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.proposals[p.EverHash] = copyProposal(p)
+	return nil
+}
+
+func (m *MemoryApprovalStorage) GetProposal(everHash string) (schema.Proposal, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	p, ok := m.proposals[everHash]
+	if !ok {
+		return schema.Proposal{}, schema.ERR_PROPOSAL_NOT_EXIST
+	}
+	return copyProposal(p), nil
+}
+
+func (m *MemoryApprovalStorage) Proposals(status string) ([]schema.Proposal, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	ps := make([]schema.Proposal, 0)
+	for _, p := range m.proposals {
+		if status == "" || p.Status == status {
+			ps = append(ps, copyProposal(p))
+		}
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		return ps[i].CreatedAt < ps[j].CreatedAt
+	})
+	return ps, nil
+}
+
+func copyProposal(p schema.Proposal) schema.Proposal {
+	approvals := make(map[string]string, len(p.Approvals))
+	for k, v := range p.Approvals {
+		approvals[k] = v
+	}
+	p.Approvals = approvals
+	return p
+}
@@ -0,0 +1,253 @@
+package sdk
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/everFinance/goar"
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApprovalWorkflow(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	s := srv.newSDK(t)
+
+	ecc01, ecc02 := newTestEccSigner(t), newTestEccSigner(t)
+	prv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ar := goar.NewSignerByPrivateKey(prv)
+	outsider := newTestEccSigner(t)
+
+	_, err = s.NewApprovalWorkflow([]string{ecc01.Address.String()}, 2, nil)
+	assert.Equal(t, schema.ERR_INVALID_THRESHOLD, err)
+	w, err := s.NewApprovalWorkflow([]string{ecc01.Address.String(), ecc02.Address.String(), ar.Address}, 2, nil)
+	assert.NoError(t, err)
+
+	p, err := w.ProposeTransfer(testTokenTag, big.NewInt(1000), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	assert.Equal(t, schema.ProposalStatusPending, p.Status)
+	assert.Equal(t, p.Tx.HexHash(), p.EverHash)
+
+	// outsider
+	sig, err := SignApproval(outsider, p.EverHash)
+	assert.NoError(t, err)
+	_, err = w.Approve(p.EverHash, outsider.Address.String(), sig)
+	assert.Equal(t, schema.ERR_NOT_APPROVER, err)
+	// sig of other approver
+	sig, _ = SignApproval(ecc02, p.EverHash)
+	_, err = w.Approve(p.EverHash, ecc01.Address.String(), sig)
+	assert.Error(t, err)
+
+	sig, _ = SignApproval(ecc01, p.EverHash)
+	p, err = w.Approve(p.EverHash, ecc01.Address.Hex(), sig)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.ProposalStatusPending, p.Status)
+	assert.Equal(t, 1, len(p.Approvals))
+	assert.Equal(t, 0, len(srv.submitted()))
+
+	sig, err = SignApproval(ar, p.EverHash)
+	assert.NoError(t, err)
+	p, err = w.Approve(p.EverHash, ar.Address, sig)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.ProposalStatusExecuted, p.Status)
+	txs := srv.submitted()
+	assert.Equal(t, 1, len(txs))
+	assert.Equal(t, p.EverHash, txs[0].HexHash())
+
+	// closed
+	sig, _ = SignApproval(ecc02, p.EverHash)
+	_, err = w.Approve(p.EverHash, ecc02.Address.String(), sig)
+	assert.Equal(t, schema.ERR_PROPOSAL_NOT_PENDING, err)
+
+	pending, err := w.PendingProposals()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(pending))
+}
+
+func TestApprovalWorkflow_Cancel(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	s := srv.newSDK(t)
+	ecc01 := newTestEccSigner(t)
+	w, err := s.NewApprovalWorkflow([]string{ecc01.Address.String()}, 1, NewMemoryApprovalStorage())
+	assert.NoError(t, err)
+
+	p, err := w.ProposeTransfer(testTokenTag, big.NewInt(1000), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	_, err = w.Cancel(p.EverHash)
+	assert.NoError(t, err)
+	sig, _ := SignApproval(ecc01, p.EverHash)
+	_, err = w.Approve(p.EverHash, ecc01.Address.String(), sig)
+	assert.Equal(t, schema.ERR_PROPOSAL_NOT_PENDING, err)
+	assert.Equal(t, 0, len(srv.submitted()))
+
+	_, err = w.Proposal("0x01")
+	assert.Equal(t, schema.ERR_PROPOSAL_NOT_EXIST, err)
+}
+
+func TestApprovalWorkflow_Expired(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	s := srv.newSDK(t)
+	ecc01 := newTestEccSigner(t)
+	w, err := s.NewApprovalWorkflow([]string{ecc01.Address.String()}, 1, nil)
+	assert.NoError(t, err)
+
+	p, err := w.ProposeTransfer(testTokenTag, big.NewInt(1000), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	assert.Equal(t, p.CreatedAt+int64(DefaultProposalTTL/time.Second), p.ExpiredAt)
+
+	w.SetProposalTTL(-time.Second)
+	p, err = w.ProposeTransfer(testTokenTag, big.NewInt(1000), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	time.Sleep(time.Second)
+	sig, _ := SignApproval(ecc01, p.EverHash)
+	_, err = w.Approve(p.EverHash, ecc01.Address.String(), sig)
+	assert.Equal(t, schema.ERR_PROPOSAL_EXPIRED, err)
+	p, err = w.Proposal(p.EverHash)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.ProposalStatusExpired, p.Status)
+	assert.Equal(t, 0, len(srv.submitted()))
+}
+
+func TestApprovalWorkflow_Execute(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	s := srv.newSDK(t)
+	ecc01 := newTestEccSigner(t)
+	w, err := s.NewApprovalWorkflow([]string{ecc01.Address.String()}, 1, nil)
+	assert.NoError(t, err)
+
+	// not rejected by everPay, keep pending for retry
+	srv.update(func(p *testPayServer) { p.submitErr = "service unavailable" })
+	p, err := w.ProposeTransfer(testTokenTag, big.NewInt(1000), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	sig, _ := SignApproval(ecc01, p.EverHash)
+	p, err = w.Approve(p.EverHash, ecc01.Address.String(), sig)
+	assert.Error(t, err)
+	assert.Equal(t, schema.ProposalStatusPending, p.Status)
+	assert.Equal(t, "service unavailable", p.Error)
+
+	srv.update(func(p *testPayServer) { p.submitErr = "" })
+	p, err = w.Execute(p.EverHash)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.ProposalStatusExecuted, p.Status)
+	assert.Equal(t, "", p.Error)
+	assert.Equal(t, 1, len(srv.submitted()))
+	_, err = w.Execute(p.EverHash)
+	assert.Equal(t, schema.ERR_PROPOSAL_NOT_PENDING, err)
+
+	// rejected by everPay is final
+	srv.update(func(p *testPayServer) { p.submitErr = `{"error":"err_invalid_signature"}` })
+	p, err = w.ProposeTransfer(testTokenTag, big.NewInt(1000), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	sig, _ = SignApproval(ecc01, p.EverHash)
+	p, err = w.Approve(p.EverHash, ecc01.Address.String(), sig)
+	assert.EqualError(t, err, "err_invalid_signature")
+	assert.Equal(t, schema.ProposalStatusFailed, p.Status)
+	_, err = w.Execute(p.EverHash)
+	assert.Equal(t, schema.ERR_PROPOSAL_NOT_PENDING, err)
+}
+
+func TestApprovalWorkflow_ExecuteLanded(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	s := srv.newSDK(t)
+	ecc01 := newTestEccSigner(t)
+	w, err := s.NewApprovalWorkflow([]string{ecc01.Address.String()}, 1, nil)
+	assert.NoError(t, err)
+	land := func(everHash string) {
+		srv.update(func(p *testPayServer) {
+			p.txResps = append(p.txResps, schema.TxResponse{EverHash: everHash, Status: schema.TxStatusConfirmed})
+		})
+	}
+
+	// response lost but tx executed
+	srv.update(func(p *testPayServer) { p.submitErr = "service unavailable" })
+	p, err := w.ProposeTransfer(testTokenTag, big.NewInt(1000), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	land(p.EverHash)
+	sig, _ := SignApproval(ecc01, p.EverHash)
+	p, err = w.Approve(p.EverHash, ecc01.Address.String(), sig)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.ProposalStatusExecuted, p.Status)
+	assert.Equal(t, "", p.Error)
+
+	// duplicate submission rejected, tx executed by last submission
+	srv.update(func(p *testPayServer) { p.submitErr = `{"error":"err_invalid_nonce"}` })
+	p, err = w.ProposeTransfer(testTokenTag, big.NewInt(1000), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	land(p.EverHash)
+	sig, _ = SignApproval(ecc01, p.EverHash)
+	p, err = w.Approve(p.EverHash, ecc01.Address.String(), sig)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.ProposalStatusExecuted, p.Status)
+
+	// found before retry, not submitted again
+	srv.update(func(p *testPayServer) { p.submitErr = "service unavailable" })
+	p, err = w.ProposeTransfer(testTokenTag, big.NewInt(1000), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	sig, _ = SignApproval(ecc01, p.EverHash)
+	p, err = w.Approve(p.EverHash, ecc01.Address.String(), sig)
+	assert.Error(t, err)
+	assert.Equal(t, schema.ProposalStatusPending, p.Status)
+	land(p.EverHash)
+	srv.update(func(p *testPayServer) { p.submitErr = "" })
+	p, err = w.Execute(p.EverHash)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.ProposalStatusExecuted, p.Status)
+	assert.Equal(t, 0, len(srv.submitted()))
+}
+
+func TestApprovalWorkflow_HashMismatch(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	s := srv.newSDK(t)
+	ecc01, ecc02 := newTestEccSigner(t), newTestEccSigner(t)
+	storage := NewMemoryApprovalStorage()
+	w, err := s.NewApprovalWorkflow([]string{ecc01.Address.String(), ecc02.Address.String()}, 2, storage)
+	assert.NoError(t, err)
+
+	p, err := w.ProposeTransfer(testTokenTag, big.NewInt(1000), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	sig, _ := SignApproval(ecc01, p.EverHash)
+	_, err = w.Approve(p.EverHash, ecc01.Address.String(), sig)
+	assert.NoError(t, err)
+
+	// stored tx replaced after approved
+	p, err = storage.GetProposal(p.EverHash)
+	assert.NoError(t, err)
+	p.Tx.To = "0x61EbF673c200646236B2c53465bcA0699455d5FA"
+	assert.NoError(t, storage.SaveProposal(p))
+	sig, _ = SignApproval(ecc02, p.EverHash)
+	_, err = w.Approve(p.EverHash, ecc02.Address.String(), sig)
+	assert.Equal(t, schema.ERR_PROPOSAL_HASH_MISMATCH, err)
+	p.Approvals[ecc02.Address.String()] = sig
+	assert.NoError(t, storage.SaveProposal(p))
+	_, err = w.Execute(p.EverHash)
+	assert.Equal(t, schema.ERR_PROPOSAL_HASH_MISMATCH, err)
+	assert.Equal(t, 0, len(srv.submitted()))
+}
+
+func TestApprovalWorkflow_ExecuteNotEnough(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	s := srv.newSDK(t)
+	ecc01, ecc02 := newTestEccSigner(t), newTestEccSigner(t)
+	w, err := s.NewApprovalWorkflow([]string{ecc01.Address.String(), ecc02.Address.String()}, 2, nil)
+	assert.NoError(t, err)
+
+	p, err := w.ProposeTransfer(testTokenTag, big.NewInt(1000), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	sig, _ := SignApproval(ecc01, p.EverHash)
+	_, err = w.Approve(p.EverHash, ecc01.Address.String(), sig)
+	assert.NoError(t, err)
+	_, err = w.Execute(p.EverHash)
+	assert.Equal(t, schema.ERR_PROPOSAL_NOT_ENOUGH, err)
+	assert.Equal(t, 0, len(srv.submitted()))
+}
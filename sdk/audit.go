@@ -0,0 +1,273 @@
+package sdk
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+)
+
+// AuditSink append only store of audit records
+type AuditSink interface {
+	Append(record schema.AuditRecord) error
+}
+
+// FileAuditSink append hash chained audit records to file as json lines.
+// The chain only detects the change of records in the middle, without key anyone who can write the file
+// is able to truncate or rewrite the whole chain with valid hashes. Use a secret key to chain the records
+// by HMAC-SHA256, and keep the Head out of the audit file (e.g. remote log) to detect the truncation.
+type FileAuditSink struct {
+	lock     sync.Mutex
+	file     *os.File
+	key      []byte
+	seq      int64
+	prevHash string
+}
+
+// NewFileAuditSink open or create the audit file, the exist records are verified before append.
+// key: HMAC key of hash chain, sha256 is used if empty
+func NewFileAuditSink(path string, key []byte) (*FileAuditSink, error) {
+	last, err := VerifyAuditFile(path, key)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileAuditSink{
+		file:     f,
+		key:      key,
+		seq:      last.Seq,
+		prevHash: last.Hash,
+	}, nil
+}
+
+func (f *FileAuditSink) Append(record schema.AuditRecord) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	record.Seq = f.seq + 1
+	record.PrevHash = f.prevHash
+	record.Hash = AuditRecordHash(record, f.key)
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err = f.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if err = f.file.Sync(); err != nil {
+		return err
+	}
+	f.seq = record.Seq
+	f.prevHash = record.Hash
+	return nil
+}
+
+// Head return the seq and hash of the last record, the truncated file is detected by VerifyAuditHead with the saved head
+func (f *FileAuditSink) Head() (seq int64, hash string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.seq, f.prevHash
+}
+
+func (f *FileAuditSink) Close() error {
+	return f.file.Close()
+}
+
+// AuditRecordHash HMAC-SHA256 with key or sha256 if key is empty of (PrevHash + json(record without Hash))
+func AuditRecordHash(record schema.AuditRecord, key []byte) string {
+	record.Hash = ""
+	by, _ := json.Marshal(record)
+	if len(key) == 0 {
+		hash := sha256.Sum256(append([]byte(record.PrevHash), by...))
+		return hex.EncodeToString(hash[:])
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(record.PrevHash))
+	mac.Write(by)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyAuditFile verify the hash chain of audit file, return the last record
+func VerifyAuditFile(path string, key []byte) (last schema.AuditRecord, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return VerifyAuditRecords(f, key)
+}
+
+// VerifyAuditRecords verify the hash chain of json lines audit records, return the last record
+func VerifyAuditRecords(r io.Reader, key []byte) (last schema.AuditRecord, err error) {
+	return verifyAuditRecords(r, key, func(schema.AuditRecord) {})
+}
+
+// VerifyAuditHead verify the audit file contains the head returned by FileAuditSink.Head before,
+// the records appended after head are allowed
+func VerifyAuditHead(path string, key []byte, seq int64, hash string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	found := false
+	_, err = verifyAuditRecords(f, key, func(record schema.AuditRecord) {
+		found = found || (record.Seq == seq && record.Hash == hash)
+	})
+	if err != nil {
+		return err
+	}
+	if !found && seq > 0 {
+		return fmt.Errorf("%w: head seq %d not found", schema.ERR_AUDIT_TAMPERED, seq)
+	}
+	return nil
+}
+
+func verifyAuditRecords(r io.Reader, key []byte, visit func(record schema.AuditRecord)) (last schema.AuditRecord, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 10*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		record := schema.AuditRecord{}
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return last, fmt.Errorf("%w: line %d: %v", schema.ERR_AUDIT_TAMPERED, line, err)
+		}
+		if record.Seq != last.Seq+1 || record.PrevHash != last.Hash || !hmac.Equal([]byte(record.Hash), []byte(AuditRecordHash(record, key))) {
+			return last, fmt.Errorf("%w: line %d, seq %d", schema.ERR_AUDIT_TAMPERED, line, record.Seq)
+		}
+		visit(record)
+		last = record
+	}
+	err = scanner.Err()
+	return
+}
+
+// SetPolicy check the policy tokens are listed by everPay and set it, nil p remove the policy
+func (s *SDK) SetPolicy(p *PolicyEngine) error {
+	if p != nil {
+		tokens := make(map[string]bool)
+		for tag := range s.tokens {
+			if normalized, err := NormalizeTag(tag); err == nil {
+				tokens[normalized] = true
+			}
+		}
+		for _, tag := range p.Tokens() {
+			if !tokens[tag] {
+				return fmt.Errorf("policy token %s: %w", tag, schema.ERR_TOKEN_NOT_EXIST)
+			}
+		}
+	}
+	// policy and audit are used by signAndSubmitTx with sendTxLocker
+	s.sendTxLocker.Lock()
+	s.policy = p
+	s.sendTxLocker.Unlock()
+	return nil
+}
+
+func (s *SDK) SetAuditSink(sink AuditSink) {
+	s.sendTxLocker.Lock()
+	s.audit = sink
+	s.sendTxLocker.Unlock()
+}
+
+// AuditConfirm query the status of tx from everPay and append to audit sink
+func (s *SDK) AuditConfirm(everHash string) (string, error) {
+	record := schema.AuditRecord{
+		Time:     time.Now().UnixMilli(),
+		Event:    schema.AuditEventConfirm,
+		Signer:   s.AccId,
+		EverHash: everHash,
+	}
+	tx, err := s.Cli.TxByHash(everHash)
+	if err != nil {
+		record.Error = err.Error()
+	} else if tx.Tx != nil {
+		record.Status = tx.Tx.Status
+		record.TokenTag = tx.Tx.Tag()
+		record.Action = tx.Tx.Action
+		record.To = tx.Tx.To
+		record.Amount = tx.Tx.Amount
+		record.Fee = tx.Tx.Fee
+		record.Nonce = fmt.Sprintf("%d", tx.Tx.Nonce)
+	}
+	s.sendTxLocker.Lock()
+	s.appendAudit(record)
+	s.sendTxLocker.Unlock()
+	return record.Status, err
+}
+
+// checkPolicy evaluate tx by policy, the decision is appended to audit sink
+func (s *SDK) checkPolicy(tokenTag, action, to, amount, fee, nonce string) error {
+	if s.policy == nil {
+		return nil
+	}
+	record, err := s.policy.Evaluate(s.AccId, tokenTag, action, to, amount, fee, nonce)
+	s.appendAudit(record)
+	return err
+}
+
+// checkBundlePolicy evaluate the bundle items of tokenTag by policy
+func (s *SDK) checkBundlePolicy(tokenTag, salt string, items []schema.BundleItem) error {
+	if s.policy == nil {
+		return nil
+	}
+	record, err := s.policy.EvaluateBundle(s.AccId, tokenTag, salt, items)
+	s.appendAudit(record)
+	return err
+}
+
+// auditSign record the signed tx, tx will not be submitted if failed
+func (s *SDK) auditSign(tokenTag string, everTx *schema.Transaction) error {
+	if s.audit == nil {
+		return nil
+	}
+	record := txAuditRecord(schema.AuditEventSign, s.AccId, tokenTag, everTx)
+	record.Preimage = everTx.String()
+	if s.policy != nil {
+		record.Decision = schema.PolicyDecisionAllow
+	}
+	return s.audit.Append(record)
+}
+
+func (s *SDK) auditSubmit(tokenTag string, everTx *schema.Transaction, submitErr error) {
+	record := txAuditRecord(schema.AuditEventSubmit, s.AccId, tokenTag, everTx)
+	if submitErr != nil {
+		record.Error = submitErr.Error()
+	}
+	s.appendAudit(record)
+}
+
+// appendAudit must be called with sendTxLocker
+func (s *SDK) appendAudit(record schema.AuditRecord) {
+	if s.audit == nil {
+		return
+	}
+	if err := s.audit.Append(record); err != nil {
+		log.Error("append audit record failed", "event", record.Event, "err", err)
+	}
+}
+
+func txAuditRecord(event, signer, tokenTag string, everTx *schema.Transaction) schema.AuditRecord {
+	return schema.AuditRecord{
+		Time:     time.Now().UnixMilli(),
+		Event:    event,
+		Signer:   signer,
+		TokenTag: tokenTag,
+		Action:   everTx.Action,
+		To:       everTx.To,
+		Amount:   everTx.Amount,
+		Fee:      everTx.Fee,
+		Nonce:    everTx.Nonce,
+		EverHash: everTx.HexHash(),
+	}
+}
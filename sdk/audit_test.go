@@ -0,0 +1,123 @@
+package sdk
+
+import (
+	"bytes"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileAuditSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileAuditSink(path, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Append(schema.AuditRecord{Event: schema.AuditEventSign, EverHash: "0x01"}))
+	assert.NoError(t, sink.Append(schema.AuditRecord{Event: schema.AuditEventSubmit, EverHash: "0x01"}))
+	assert.NoError(t, sink.Close())
+
+	// reopen and continue the chain
+	sink, err = NewFileAuditSink(path, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Append(schema.AuditRecord{Event: schema.AuditEventConfirm, EverHash: "0x01", Status: "success"}))
+	assert.NoError(t, sink.Close())
+
+	last, err := VerifyAuditFile(path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), last.Seq)
+	assert.Equal(t, "success", last.Status)
+
+	// tamper
+	by, _ := os.ReadFile(path)
+	assert.NoError(t, os.WriteFile(path, bytes.Replace(by, []byte(`"event":"submit"`), []byte(`"event":"sign"`), 1), 0600))
+	_, err = VerifyAuditFile(path, nil)
+	assert.ErrorIs(t, err, schema.ERR_AUDIT_TAMPERED)
+	_, err = NewFileAuditSink(path, nil)
+	assert.ErrorIs(t, err, schema.ERR_AUDIT_TAMPERED)
+
+	// delete a line
+	lines := bytes.Split(bytes.TrimSpace(by), []byte("\n"))
+	assert.NoError(t, os.WriteFile(path, append(bytes.Join([][]byte{lines[0], lines[2]}, []byte("\n")), '\n'), 0600))
+	_, err = VerifyAuditFile(path, nil)
+	assert.ErrorIs(t, err, schema.ERR_AUDIT_TAMPERED)
+}
+
+func TestFileAuditSink_Key(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	key := []byte("audit key")
+	sink, err := NewFileAuditSink(path, key)
+	assert.NoError(t, err)
+	for _, hash := range []string{"0x01", "0x02", "0x03"} {
+		assert.NoError(t, sink.Append(schema.AuditRecord{Event: schema.AuditEventSign, EverHash: hash}))
+	}
+	seq, head := sink.Head()
+	assert.Equal(t, int64(3), seq)
+	assert.NoError(t, sink.Close())
+	assert.NoError(t, VerifyAuditHead(path, key, seq, head))
+	// key is required to verify
+	_, err = VerifyAuditFile(path, nil)
+	assert.ErrorIs(t, err, schema.ERR_AUDIT_TAMPERED)
+	_, err = VerifyAuditFile(path, []byte("other key"))
+	assert.ErrorIs(t, err, schema.ERR_AUDIT_TAMPERED)
+
+	// truncated chain is valid, but head is lost
+	by, _ := os.ReadFile(path)
+	lines := bytes.Split(bytes.TrimSpace(by), []byte("\n"))
+	assert.NoError(t, os.WriteFile(path, append(bytes.Join(lines[:2], []byte("\n")), '\n'), 0600))
+	_, err = VerifyAuditFile(path, key)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, VerifyAuditHead(path, key, seq, head), schema.ERR_AUDIT_TAMPERED)
+
+	// rewritten chain without key is invalid
+	forged, err := NewFileAuditSink(filepath.Join(t.TempDir(), "forged.log"), nil)
+	assert.NoError(t, err)
+	for _, hash := range []string{"0x01", "0x02", "0x03"} {
+		assert.NoError(t, forged.Append(schema.AuditRecord{Event: schema.AuditEventSign, EverHash: hash}))
+	}
+	assert.NoError(t, forged.Close())
+	by, _ = os.ReadFile(forged.file.Name())
+	assert.NoError(t, os.WriteFile(path, by, 0600))
+	assert.ErrorIs(t, VerifyAuditHead(path, key, seq, head), schema.ERR_AUDIT_TAMPERED)
+
+	// records appended after head are allowed
+	assert.NoError(t, os.Remove(path))
+	sink, err = NewFileAuditSink(path, key)
+	assert.NoError(t, err)
+	assert.NoError(t, sink.Append(schema.AuditRecord{Event: schema.AuditEventSign, EverHash: "0x01"}))
+	seq, head = sink.Head()
+	assert.NoError(t, sink.Append(schema.AuditRecord{Event: schema.AuditEventSign, EverHash: "0x02"}))
+	assert.NoError(t, sink.Close())
+	assert.NoError(t, VerifyAuditHead(path, key, seq, head))
+}
+
+func TestSDK_Audit(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	s := srv.newSDK(t)
+	sink := &testAuditSink{}
+	s.SetAuditSink(sink)
+
+	tx, err := s.Transfer(testTokenTag, big.NewInt(10), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(sink.records))
+	assert.Equal(t, schema.AuditEventSign, sink.records[0].Event)
+	assert.Equal(t, tx.String(), sink.records[0].Preimage)
+	assert.Equal(t, tx.HexHash(), sink.records[0].EverHash)
+	assert.Equal(t, schema.AuditEventSubmit, sink.records[1].Event)
+	assert.Equal(t, "", sink.records[1].Error)
+
+	other := "arweave,ethereum-ar-AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA,0x4fadc7a98f2dc96510e42dd1a74141eeae0c1543"
+	bundle := GenBundle([]schema.BundleItem{
+		{Tag: testTokenTag, From: s.AccId, To: "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", Amount: "1"},
+		{Tag: other, From: s.AccId, To: "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", Amount: "1"},
+	}, time.Now().Unix()+100)
+	_, err = s.SignBundleData(bundle)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.AuditEventSign, sink.records[2].Event)
+	assert.Equal(t, testTokenTag+","+other, sink.records[2].TokenTag)
+	assert.Equal(t, bundle.HashHex(), sink.records[2].EverHash)
+}
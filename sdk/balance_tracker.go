@@ -0,0 +1,252 @@
+package sdk
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/tidwall/gjson"
+)
+
+const defaultPendingTimeout = 10 * time.Minute
+
+// LocalBalance Available = Balance - Pending
+type LocalBalance struct {
+	Tag       string
+	Balance   *big.Int // balance from everPay
+	Pending   *big.Int // debits of submitted txs which not found in everPay txs yet
+	Available *big.Int
+}
+
+type pendingDebit struct {
+	debits    map[string]*big.Int // tag -> amount + fee
+	createdAt time.Time
+}
+
+// BalanceTracker track the balances of accId in process, apply optimistic debits when tx submitted,
+// reconcile by the txs of accId and refetch balances periodically
+type BalanceTracker struct {
+	client *Client
+	accId  string
+
+	lock     sync.RWMutex
+	balances map[string]*big.Int     // tag -> balance
+	pending  map[string]pendingDebit // everHash -> debits
+	executed map[string]time.Time    // everHash -> received time, reconcile the tx received before AddPending
+
+	pendingTimeout time.Duration
+	sub            *SubscribeTx
+	quit           chan struct{}
+	quitOnce       sync.Once
+}
+
+// NewBalanceTracker refetchInterval: refetch balances from everPay periodically, default 1 minute
+func (c *Client) NewBalanceTracker(accId string, refetchInterval time.Duration) (*BalanceTracker, error) {
+	if refetchInterval <= 0 {
+		refetchInterval = time.Minute
+	}
+	b := &BalanceTracker{
+		client:         c,
+		accId:          accId,
+		balances:       make(map[string]*big.Int),
+		pending:        make(map[string]pendingDebit),
+		executed:       make(map[string]time.Time),
+		pendingTimeout: defaultPendingTimeout,
+		quit:           make(chan struct{}),
+	}
+
+	// subscribe txs after the latest tx of accId
+	txs, err := c.Txs(0, OrderByDesc, 1, schema.TxOpts{Address: accId})
+	if err != nil {
+		return nil, err
+	}
+	if err = b.Refetch(); err != nil {
+		return nil, err
+	}
+	b.sub = c.SubscribeTxs(schema.FilterQuery{
+		StartCursor: txs.NextCursor,
+		Address:     accId,
+	})
+	go b.run(refetchInterval)
+	return b, nil
+}
+
+// TrackBalance track the balances of sdk AccId, txs sent by sdk are added as pending automatically
+func (s *SDK) TrackBalance(refetchInterval time.Duration) (*BalanceTracker, error) {
+	b, err := s.Cli.NewBalanceTracker(s.AccId, refetchInterval)
+	if err != nil {
+		return nil, err
+	}
+	// balanceTracker is used by signAndSubmitTx with sendTxLocker
+	s.sendTxLocker.Lock()
+	s.balanceTracker = b
+	s.sendTxLocker.Unlock()
+	return b, nil
+}
+
+// AddPending add the debits of submitted tx, the tx already received from everPay is ignored
+func (b *BalanceTracker) AddPending(tx *schema.Transaction) {
+	debits := txDebits(b.accId, tx)
+	if len(debits) == 0 {
+		return
+	}
+	everHash := strings.ToLower(tx.HexHash())
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if _, ok := b.executed[everHash]; ok {
+		return
+	}
+	b.pending[everHash] = pendingDebit{debits: debits, createdAt: time.Now()}
+}
+
+func (b *BalanceTracker) Balance(tag string) LocalBalance {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	return b.localBalance(tag)
+}
+
+// Balances return balances of all tokens sorted by tag
+func (b *BalanceTracker) Balances() []LocalBalance {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	tags := make(map[string]struct{})
+	for tag := range b.balances {
+		tags[tag] = struct{}{}
+	}
+	for _, p := range b.pending {
+		for tag := range p.debits {
+			tags[tag] = struct{}{}
+		}
+	}
+	res := make([]LocalBalance, 0, len(tags))
+	for tag := range tags {
+		res = append(res, b.localBalance(tag))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Tag < res[j].Tag
+	})
+	return res
+}
+
+// Refetch update balances from everPay and drop the timeout pending debits
+func (b *BalanceTracker) Refetch() error {
+	accBals, err := b.client.Balances(b.accId)
+	if err != nil {
+		return err
+	}
+	balances := make(map[string]*big.Int, len(accBals.Balances))
+	for _, bal := range accBals.Balances {
+		amount, ok := new(big.Int).SetString(bal.Amount, 10)
+		if !ok {
+			return schema.ERR_INVALID_AMOUNT
+		}
+		balances[bal.Tag] = amount
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.balances = balances
+	for everHash, p := range b.pending {
+		if time.Since(p.createdAt) > b.pendingTimeout {
+			log.Warn("pending tx timeout", "everHash", everHash)
+			delete(b.pending, everHash)
+		}
+	}
+	for everHash, t := range b.executed {
+		if time.Since(t) > b.pendingTimeout {
+			delete(b.executed, everHash)
+		}
+	}
+	return nil
+}
+
+func (b *BalanceTracker) Close() {
+	b.quitOnce.Do(func() {
+		close(b.quit)
+		b.sub.Unsubscribe()
+	})
+}
+
+func (b *BalanceTracker) run(refetchInterval time.Duration) {
+	ticker := time.NewTicker(refetchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case tx := <-b.sub.Subscribe():
+			// tx executed, balances on everPay include it
+			everHash := strings.ToLower(tx.EverHash)
+			b.lock.Lock()
+			delete(b.pending, everHash)
+			b.executed[everHash] = time.Now()
+			b.lock.Unlock()
+			if err := b.Refetch(); err != nil {
+				log.Error("refetch balances failed", "err", err)
+			}
+		case <-ticker.C:
+			if err := b.Refetch(); err != nil {
+				log.Error("refetch balances failed", "err", err)
+			}
+		case <-b.quit:
+			return
+		}
+	}
+}
+
+func (b *BalanceTracker) localBalance(tag string) LocalBalance {
+	bal := new(big.Int)
+	if v, ok := b.balances[tag]; ok {
+		bal.Set(v)
+	}
+	pending := new(big.Int)
+	for _, p := range b.pending {
+		if v, ok := p.debits[tag]; ok {
+			pending.Add(pending, v)
+		}
+	}
+	return LocalBalance{
+		Tag:       tag,
+		Balance:   bal,
+		Pending:   pending,
+		Available: new(big.Int).Sub(bal, pending),
+	}
+}
+
+// txDebits return the debits of accId in tx, key: tag
+func txDebits(accId string, tx *schema.Transaction) map[string]*big.Int {
+	debits := make(map[string]*big.Int)
+	add := func(tag, amount string) {
+		v, ok := new(big.Int).SetString(amount, 10)
+		if !ok {
+			return
+		}
+		if _, ok = debits[tag]; !ok {
+			debits[tag] = new(big.Int)
+		}
+		debits[tag].Add(debits[tag], v)
+	}
+
+	accId = utils.FormatAccId(accId)
+	if utils.FormatAccId(tx.From) == accId {
+		if tx.Action != schema.TxActionMint {
+			add(tx.Tag(), tx.Amount)
+		}
+		add(tx.Tag(), tx.Fee)
+	}
+	if tx.Action == schema.TxActionBundle {
+		for _, item := range gjson.Get(tx.Data, "bundle.items").Array() {
+			if utils.FormatAccId(item.Get("from").String()) == accId {
+				add(item.Get("tag").String(), item.Get("amount").String())
+			}
+		}
+	}
+	for tag, v := range debits {
+		if v.Sign() == 0 {
+			delete(debits, tag)
+		}
+	}
+	return debits
+}
@@ -0,0 +1,126 @@
+package sdk
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTransferTx(from, to, amount, fee, nonce string) *schema.Transaction {
+	return &schema.Transaction{
+		TokenSymbol: "USDT", Action: schema.TxActionTransfer, From: from, To: to, Amount: amount, Fee: fee,
+		Nonce: nonce, TokenID: "0xdac17f958d2ee523a2206206994597c13d831ec7", ChainType: "ethereum", ChainID: "1",
+		Version: schema.TxVersionV1,
+	}
+}
+
+// executeTx add tx to everPay txs and update balance of from
+func executeTx(srv *testPayServer, tx *schema.Transaction, balance string) {
+	srv.update(func(p *testPayServer) {
+		p.txResps = append(p.txResps, schema.TxResponse{
+			RawId: int64(len(p.txResps) + 1), Action: tx.Action, TokenSymbol: tx.TokenSymbol, TokenID: tx.TokenID,
+			ChainType: tx.ChainType, ChainID: tx.ChainID, From: tx.From, To: tx.To, Amount: tx.Amount, Fee: tx.Fee,
+			EverHash: tx.HexHash(),
+		})
+		p.balances[tx.From][tx.Tag()] = balance
+	})
+}
+
+func TestTxDebits(t *testing.T) {
+	acc := testSignerAddr
+	other := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+
+	assert.Equal(t, map[string]*big.Int{testTokenTag: big.NewInt(11)}, txDebits(acc, testTransferTx(acc, other, "10", "1", "1")))
+	// accId case
+	assert.Equal(t, map[string]*big.Int{testTokenTag: big.NewInt(11)}, txDebits("0x3d7e9dfbc58952fdacee2a5c69367c8478474d82", testTransferTx(acc, other, "10", "1", "1")))
+	assert.Equal(t, map[string]*big.Int{}, txDebits(acc, testTransferTx(other, acc, "10", "1", "1")))
+	assert.Equal(t, map[string]*big.Int{}, txDebits(acc, testTransferTx(acc, other, "0", "0", "1")))
+
+	mint := testTransferTx(acc, acc, "10", "1", "1")
+	mint.Action = schema.TxActionMint
+	assert.Equal(t, map[string]*big.Int{testTokenTag: big.NewInt(1)}, txDebits(acc, mint))
+
+	bundle := testTransferTx(other, other, "0", "2", "1")
+	bundle.Action = schema.TxActionBundle
+	bundle.Data = `{"bundle":{"items":[
+		{"tag":"` + testTokenTag + `","from":"0x3d7e9dfbc58952fdacee2a5c69367c8478474d82","to":"` + other + `","amount":"5"},
+		{"tag":"` + testTokenTag + `","from":"` + acc + `","to":"` + other + `","amount":"6"},
+		{"tag":"bsc-usdt-0x55d398326f99059ff775485246999027b3197955","from":"` + acc + `","to":"` + other + `","amount":"7"},
+		{"tag":"` + testTokenTag + `","from":"` + other + `","to":"` + acc + `","amount":"8"}]}}`
+	assert.Equal(t, map[string]*big.Int{
+		testTokenTag: big.NewInt(11),
+		"bsc-usdt-0x55d398326f99059ff775485246999027b3197955": big.NewInt(7),
+	}, txDebits(acc, bundle))
+}
+
+func TestBalanceTracker(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.balances[testSignerAddr] = map[string]string{testTokenTag: "100"}
+	other := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+
+	b, err := NewClient(srv.URL).NewBalanceTracker(testSignerAddr, time.Minute)
+	assert.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, LocalBalance{Tag: testTokenTag, Balance: big.NewInt(100), Pending: big.NewInt(0), Available: big.NewInt(100)},
+		b.Balance(testTokenTag))
+
+	tx01 := testTransferTx(testSignerAddr, other, "10", "1", "1")
+	tx02 := testTransferTx(testSignerAddr, other, "20", "1", "2")
+	b.AddPending(tx01)
+	b.AddPending(tx02)
+	assert.Equal(t, LocalBalance{Tag: testTokenTag, Balance: big.NewInt(100), Pending: big.NewInt(32), Available: big.NewInt(68)},
+		b.Balance(testTokenTag))
+
+	executeTx(srv, tx01, "89")
+	assert.Eventually(t, func() bool {
+		return b.Balance(testTokenTag).Pending.Cmp(big.NewInt(21)) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, LocalBalance{Tag: testTokenTag, Balance: big.NewInt(89), Pending: big.NewInt(21), Available: big.NewInt(68)},
+		b.Balance(testTokenTag))
+
+	executeTx(srv, tx02, "68")
+	assert.Eventually(t, func() bool {
+		return b.Balance(testTokenTag).Pending.Sign() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []LocalBalance{{Tag: testTokenTag, Balance: big.NewInt(68), Pending: big.NewInt(0), Available: big.NewInt(68)}},
+		b.Balances())
+}
+
+func TestBalanceTracker_ReceivedBeforeAddPending(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.balances[testSignerAddr] = map[string]string{testTokenTag: "100"}
+
+	b, err := NewClient(srv.URL).NewBalanceTracker(testSignerAddr, time.Minute)
+	assert.NoError(t, err)
+	defer b.Close()
+
+	// tx is received by subscription before AddPending is called by sender
+	tx := testTransferTx(testSignerAddr, "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "10", "1", "1")
+	executeTx(srv, tx, "89")
+	assert.Eventually(t, func() bool {
+		return b.Balance(testTokenTag).Balance.Cmp(big.NewInt(89)) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	b.AddPending(tx)
+	assert.Equal(t, LocalBalance{Tag: testTokenTag, Balance: big.NewInt(89), Pending: big.NewInt(0), Available: big.NewInt(89)},
+		b.Balance(testTokenTag))
+}
+
+func TestSDK_TrackBalance(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.balances[testSignerAddr] = map[string]string{testTokenTag: "100"}
+	s := srv.newSDK(t)
+
+	b, err := s.TrackBalance(time.Minute)
+	assert.NoError(t, err)
+	defer b.Close()
+	_, err = s.Transfer(testTokenTag, big.NewInt(10), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(10), b.Balance(testTokenTag).Pending)
+	assert.Equal(t, big.NewInt(90), b.Balance(testTokenTag).Available)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
 	"github.com/tidwall/sjson"
 	"gopkg.in/h2non/gentleman.v2"
 	"gopkg.in/h2non/gentleman.v2/plugins/body"
@@ -14,6 +15,9 @@ import (
 
 type Client struct {
 	cli *gentleman.Client
+
+	verifyTx bool
+	chainID  int
 }
 
 func NewClient(payURL string) *Client {
@@ -26,6 +30,21 @@ func (c *Client) SetHeader(key, val string) {
 	c.cli.SetHeader(key, val)
 }
 
+// SetVerifyTx if enable, every tx returned by Txs, TxByHash and MintTx will be verified by everHash and sig,
+// Txs moves the unverified txs to Txs.Unverified, TxByHash and MintTx return the tx with verify err
+// chainID: everPay chainID, used by eid FIDO2 sig
+func (c *Client) SetVerifyTx(enable bool, chainID int) {
+	c.verifyTx = enable
+	c.chainID = chainID
+}
+
+func (c *Client) verifyTxResponse(tx schema.TxResponse) error {
+	if !c.verifyTx {
+		return nil
+	}
+	return utils.VerifyTxResponse(tx, c.chainID)
+}
+
 func (c *Client) GetInfo() (info schema.Info, err error) {
 	req := c.cli.Request()
 	req.Path("/info")
@@ -186,6 +205,44 @@ func (c *Client) AccInfo(accid string) (resp schema.RespAcc, err error) {
 	return
 }
 
+// SendEmailCode request everPay to send a verification code to email for eid account register
+func (c *Client) SendEmailCode(email string) (err error) {
+	req := c.cli.Request()
+	req.Path("/account/email/code")
+	req.Method("POST")
+	req.Use(body.JSON(map[string]string{"email": email}))
+
+	res, err := req.Send()
+	if err != nil {
+		return
+	}
+	defer res.Close()
+	if !res.Ok {
+		err = decodeRespErr(res.Bytes())
+	}
+	return
+}
+
+// VerifyEmailCode get the everPay owner sig of email code, used as mailVerify in register tx data
+func (c *Client) VerifyEmailCode(email, code string) (resp schema.RespRegister, err error) {
+	req := c.cli.Request()
+	req.Path("/account/email/verify")
+	req.Method("POST")
+	req.Use(body.JSON(map[string]string{"email": email, "code": code}))
+
+	res, err := req.Send()
+	if err != nil {
+		return
+	}
+	defer res.Close()
+	if !res.Ok {
+		err = decodeRespErr(res.Bytes())
+		return
+	}
+	err = json.Unmarshal(res.Bytes(), &resp)
+	return
+}
+
 func (c *Client) Txs(startCursor int64, orderBy string, limit int, opts schema.TxOpts) (txs schema.Txs, err error) {
 	req := c.cli.Request()
 	req.Path("/txs")
@@ -199,17 +256,18 @@ func (c *Client) Txs(startCursor int64, orderBy string, limit int, opts schema.T
 		req.AddQuery("count", fmt.Sprintf("%d", limit))
 	}
 
-	if len(opts.Address) > 0 {
-		req.AddQuery("address", opts.Address)
+	address, tokenTag, action, withoutAction := opts.ServerQuery()
+	if len(address) > 0 {
+		req.AddQuery("address", address)
 	}
-	if len(opts.TokenTag) > 0 {
-		req.AddQuery("tokenTag", opts.TokenTag)
+	if len(tokenTag) > 0 {
+		req.AddQuery("tokenTag", tokenTag)
 	}
-	if len(opts.Action) > 0 {
-		req.AddQuery("action", opts.Action)
+	if len(action) > 0 {
+		req.AddQuery("action", action)
 	}
-	if len(opts.WithoutAction) > 0 {
-		req.AddQuery("withoutAction", opts.WithoutAction)
+	if len(withoutAction) > 0 {
+		req.AddQuery("withoutAction", withoutAction)
 	}
 
 	res, err := req.Send()
@@ -223,7 +281,30 @@ func (c *Client) Txs(startCursor int64, orderBy string, limit int, opts schema.T
 	}
 
 	txs = schema.Txs{}
-	err = json.Unmarshal(res.Bytes(), &txs)
+	if err = json.Unmarshal(res.Bytes(), &txs); err != nil {
+		return
+	}
+	if num := len(txs.Txs); num > 0 {
+		txs.NextCursor = txs.Txs[num-1].RawId
+	}
+
+	// client side filters
+	result := make([]schema.TxResponse, 0, len(txs.Txs))
+	for _, tx := range txs.Txs {
+		if !opts.Match(tx) {
+			continue
+		}
+		if verifyErr := c.verifyTxResponse(tx); verifyErr != nil {
+			log.Warn("unverified tx", "everHash", tx.EverHash, "err", verifyErr)
+			if txs.Unverified == nil {
+				txs.Unverified = make(map[string]error)
+			}
+			txs.Unverified[tx.EverHash] = verifyErr
+			continue
+		}
+		result = append(result, tx)
+	}
+	txs.Txs = result
 	return
 }
 
@@ -233,6 +314,7 @@ func (c *Client) Txs(startCursor int64, orderBy string, limit int, opts schema.T
 // fq.TokenSymbol: option
 // fq.Action: option
 // fq.WithoutAction: option
+// client side filters(fq.From, fq.To, fq.Actions ...): option, see schema.TxOpts
 func (c *Client) SubscribeTxs(fq schema.FilterQuery) *SubscribeTx {
 	sub := newSubscribeTx(c, fq)
 	go sub.run()
@@ -254,7 +336,12 @@ func (c *Client) TxByHash(everHash string) (tx schema.Tx, err error) {
 	}
 
 	tx = schema.Tx{}
-	err = json.Unmarshal(res.Bytes(), &tx)
+	if err = json.Unmarshal(res.Bytes(), &tx); err != nil {
+		return
+	}
+	if tx.Tx != nil {
+		err = c.verifyTxResponse(*tx.Tx)
+	}
 	return
 }
 
@@ -301,7 +388,12 @@ func (c *Client) MintTx(chainHash string) (tx schema.Tx, err error) {
 	}
 
 	tx = schema.Tx{}
-	err = json.Unmarshal(res.Bytes(), &tx)
+	if err = json.Unmarshal(res.Bytes(), &tx); err != nil {
+		return
+	}
+	if tx.Tx != nil {
+		err = c.verifyTxResponse(*tx.Tx)
+	}
 	return
 }
 
@@ -415,6 +507,10 @@ func (c *Client) AddBlackListWithoutSig(tokenTag, from string, blackList []strin
 	return c.AssembleTxWithoutSig(tokenTag, from, from, "0", "0", schema.TxActionAddBlackList, data)
 }
 
+func (c *Client) RegisterWithoutSig(tokenTag, everId, registerData string) (everTx schema.Transaction, err error) {
+	return c.AssembleTxWithoutSig(tokenTag, everId, everId, "0", "0", schema.TxActionRegister, registerData)
+}
+
 func (c *Client) Burn102WithoutSig(tokenTag, from, amount string) (everTx schema.Transaction, err error) {
 	data, err := sjson.Set("", "targetChainType", schema.ChainTypeEverpay)
 	if err != nil {
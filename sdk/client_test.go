@@ -4,6 +4,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/everFinance/goether"
 	"github.com/everVision/everpay-kits/schema"
 	"github.com/stretchr/testify/assert"
 )
@@ -131,3 +133,82 @@ func TestGetTokens(t *testing.T) {
 	assert.NoError(t, err)
 	t.Log(len(tokens), tokens)
 }
+
+func TestClient_VerifyTx(t *testing.T) {
+	signer, err := goether.NewSigner(testSignerPrv)
+	assert.NoError(t, err)
+	everTx := testEverIdTx(signer.Address.String())
+	sig, err := signer.SignMsg([]byte(everTx.String()))
+	assert.NoError(t, err)
+
+	txRes := schema.TxResponse{
+		RawId:        1,
+		TokenSymbol:  everTx.TokenSymbol,
+		Action:       everTx.Action,
+		From:         everTx.From,
+		To:           everTx.To,
+		Amount:       everTx.Amount,
+		Fee:          everTx.Fee,
+		FeeRecipient: everTx.FeeRecipient,
+		Nonce:        1700000000000,
+		TokenID:      everTx.TokenID,
+		ChainType:    everTx.ChainType,
+		ChainID:      everTx.ChainID,
+		Version:      everTx.Version,
+		Sig:          hexutil.Encode(sig),
+		EverHash:     everTx.HexHash(),
+	}
+	// cross-chain mint tx generated by everPay server, not signed by From
+	mintTx := schema.Transaction{
+		TokenSymbol: "USDT", Action: schema.TxActionMint, From: "0x61EbF673c200646236B2c53465bcA0699455d5FA",
+		To: "0x61EbF673c200646236B2c53465bcA0699455d5FA", Amount: "10", Fee: "0", Nonce: "1700000000001",
+		TokenID: everTx.TokenID, ChainType: everTx.ChainType, ChainID: everTx.ChainID,
+		Data: `{"targetChainType":"ethereum"}`, Version: schema.TxVersionV1,
+	}
+	mintRes := schema.TxResponse{
+		RawId: 2, TokenSymbol: mintTx.TokenSymbol, Action: mintTx.Action, From: mintTx.From, To: mintTx.To,
+		Amount: mintTx.Amount, Fee: mintTx.Fee, Nonce: 1700000000001, TokenID: mintTx.TokenID,
+		ChainType: mintTx.ChainType, ChainID: mintTx.ChainID, Data: mintTx.Data, Version: mintTx.Version,
+		EverHash: mintTx.HexHash(),
+	}
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.txResps = []schema.TxResponse{txRes, mintRes}
+
+	cli := NewClient(srv.URL)
+	cli.SetVerifyTx(true, 5)
+	_, err = cli.TxByHash(txRes.EverHash)
+	assert.NoError(t, err)
+	tx, err := cli.TxByHash(mintRes.EverHash)
+	assert.Equal(t, schema.ERR_INVALID_SIGNATURE, err)
+	assert.Equal(t, mintRes, *tx.Tx)
+
+	// unverified tx is moved out of the page
+	txs, err := cli.Txs(0, OrderByAsc, 10, schema.TxOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.TxResponse{txRes}, txs.Txs)
+	assert.Equal(t, map[string]error{mintRes.EverHash: schema.ERR_INVALID_SIGNATURE}, txs.Unverified)
+	assert.Equal(t, int64(2), txs.NextCursor)
+
+	// amount changed, everHash not match
+	srv.update(func(p *testPayServer) { p.txResps[0].Amount = "101" })
+	_, err = cli.TxByHash(txRes.EverHash)
+	assert.Equal(t, schema.ERR_INVALID_EVER_HASH, err)
+	txs, err = cli.Txs(0, OrderByAsc, 10, schema.TxOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(txs.Txs))
+	assert.Equal(t, 2, len(txs.Unverified))
+
+	// amount and everHash changed, sig not match
+	everTx.Amount = "101"
+	srv.update(func(p *testPayServer) { p.txResps[0].EverHash = everTx.HexHash() })
+	_, err = cli.TxByHash(everTx.HexHash())
+	assert.Equal(t, schema.ERR_INVALID_SIGNATURE, err)
+
+	// verify disabled
+	cli.SetVerifyTx(false, 0)
+	txs, err = cli.Txs(0, OrderByAsc, 10, schema.TxOpts{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(txs.Txs))
+	assert.Nil(t, txs.Unverified)
+}
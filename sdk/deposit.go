@@ -0,0 +1,177 @@
+package sdk
+
+import (
+	"math/big"
+	"sync"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+)
+
+// DepositWatcher watch mint txs of addresses and emit normalized deposits
+type DepositWatcher struct {
+	client  *Client
+	manager *SubscribeManager
+
+	lock   sync.RWMutex
+	tokens map[string]schema.TokenInfo // tag -> TokenInfo
+	subs   map[string]*AddrSubscriber  // key: utils.FormatAccId(address)
+
+	ch       chan schema.Deposit
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+// NewDepositWatcher startCursor: rawId of tx, deposits after it will be emitted
+func (c *Client) NewDepositWatcher(startCursor int64) (*DepositWatcher, error) {
+	w := &DepositWatcher{
+		client: c,
+		subs:   make(map[string]*AddrSubscriber),
+		ch:     make(chan schema.Deposit),
+		quit:   make(chan struct{}),
+	}
+	if err := w.updateTokens(); err != nil {
+		return nil, err
+	}
+	w.manager = c.NewSubscribeManager(schema.FilterQuery{
+		StartCursor: startCursor,
+		Action:      schema.TxActionMint,
+	})
+	return w, nil
+}
+
+func (w *DepositWatcher) Subscribe() <-chan schema.Deposit {
+	return w.ch
+}
+
+// Watch add a deposit address
+func (w *DepositWatcher) Watch(address string) {
+	addr := utils.FormatAccId(address)
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.subs[addr]; ok {
+		return
+	}
+	w.subs[addr] = w.manager.watch(addr, func(tx schema.TxResponse, quit <-chan struct{}) bool {
+		return w.deliver(addr, tx, quit)
+	})
+}
+
+// Unwatch remove a deposit address
+func (w *DepositWatcher) Unwatch(address string) {
+	addr := utils.FormatAccId(address)
+	w.lock.Lock()
+	sub, ok := w.subs[addr]
+	delete(w.subs, addr)
+	w.lock.Unlock()
+	if ok {
+		sub.Unsubscribe()
+	}
+}
+
+// Cursor return the rawId of the last tx whose deposits are all received, can be used as startCursor to resume
+func (w *DepositWatcher) Cursor() int64 {
+	return w.manager.Cursor()
+}
+
+func (w *DepositWatcher) Close() {
+	w.quitOnce.Do(func() {
+		close(w.quit)
+		w.manager.Close()
+	})
+}
+
+// deliver emit the deposit of tx to addr, return false if watcher closed
+func (w *DepositWatcher) deliver(addr string, tx schema.TxResponse, quit <-chan struct{}) bool {
+	// only the receiver of mint tx is deposit
+	if utils.FormatAccId(tx.To) != addr {
+		return true
+	}
+	deposit, err := w.toDeposit(tx)
+	if err != nil {
+		log.Error("invalid deposit tx", "everHash", tx.EverHash, "err", err)
+		return true
+	}
+	select {
+	case w.ch <- deposit:
+	case <-quit:
+	case <-w.quit:
+		return false
+	}
+	return true
+}
+
+func (w *DepositWatcher) toDeposit(tx schema.TxResponse) (deposit schema.Deposit, err error) {
+	data, err := utils.DecodeTxData(tx)
+	if err != nil {
+		return
+	}
+	mintData, ok := data.(*schema.MintTxData)
+	if !ok {
+		err = schema.ERR_INVALID_TX_MINT_HASH
+		return
+	}
+	amount, ok := new(big.Int).SetString(tx.Amount, 10)
+	if !ok {
+		err = schema.ERR_INVALID_AMOUNT
+		return
+	}
+	tokenInfo, err := w.token(tx.Tag())
+	if err != nil {
+		return
+	}
+
+	targetDecimals := tokenInfo.Decimals
+	if target, ok := tokenInfo.CrossChainInfoList[mintData.TargetChainType]; ok {
+		targetDecimals = target.Decimals
+	}
+	deposit = schema.Deposit{
+		RawId:             tx.RawId,
+		EverHash:          tx.EverHash,
+		AccId:             tx.To,
+		TokenTag:          tokenInfo.Tag,
+		Symbol:            tokenInfo.Symbol,
+		Timestamp:         tx.Timestamp,
+		TargetChainType:   mintData.TargetChainType,
+		TargetChainTxHash: mintData.TargetChainTxHash,
+		Amount:            amount,
+		Decimals:          tokenInfo.Decimals,
+		TargetAmount:      utils.ConvertDecimals(amount, tokenInfo.Decimals, targetDecimals),
+		TargetDecimals:    targetDecimals,
+	}
+	return
+}
+
+// token get token info, update tokens from everPay when not found
+func (w *DepositWatcher) token(tag string) (schema.TokenInfo, error) {
+	w.lock.RLock()
+	tok, ok := w.tokens[tag]
+	w.lock.RUnlock()
+	if ok {
+		return tok, nil
+	}
+	if err := w.updateTokens(); err != nil {
+		return schema.TokenInfo{}, err
+	}
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if tok, ok = w.tokens[tag]; !ok {
+		return schema.TokenInfo{}, schema.ERR_TOKEN_NOT_EXIST
+	}
+	return tok, nil
+}
+
+func (w *DepositWatcher) updateTokens() error {
+	info, err := w.client.GetInfo()
+	if err != nil {
+		return err
+	}
+	tokens := make(map[string]schema.TokenInfo)
+	for _, t := range info.TokenList {
+		tokens[t.Tag] = t
+	}
+	w.lock.Lock()
+	w.tokens = tokens
+	w.lock.Unlock()
+	return nil
+}
@@ -0,0 +1,93 @@
+package sdk
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDepositWatcher(t *testing.T) {
+	addr := "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82"
+	other := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	token := schema.TokenInfo{
+		Tag: "bsc-usdc-0x8ac76a51cc950d9822d68b83fe1ad97b32cd580d", Symbol: "USDC", ChainType: "bsc", ChainID: "56",
+		ID: "0x8ac76a51cc950d9822d68b83fe1ad97b32cd580d", Decimals: 6,
+		CrossChainInfoList: map[string]schema.TargetChain{"bsc": {ChainType: "bsc", Decimals: 18}},
+	}
+	mint := func(rawId int64, from, to, amount, data string) schema.TxResponse {
+		return schema.TxResponse{
+			RawId: rawId, Action: schema.TxActionMint, TokenSymbol: token.Symbol, ChainType: token.ChainType,
+			TokenID: token.ID, From: from, To: to, Amount: amount, Data: data, EverHash: "0x" + big.NewInt(rawId).String(),
+			Timestamp: 1700000000000 + rawId,
+		}
+	}
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.tokens = []schema.TokenInfo{token}
+	srv.txResps = []schema.TxResponse{
+		mint(1, other, addr, "1", `{"hash":"0xa1"}`),       // before startCursor
+		mint(2, addr, other, "2", `{"hash":"0xa2"}`),       // addr is not receiver
+		mint(3, other, other, "3", `{"hash":"0xa3"}`),      // not watched
+		mint(4, other, addr, "4", `not json`),              // invalid mint data
+		mint(5, other, addr, "1234567", `{"hash":"0xa5"}`), // deposit
+	}
+
+	w, err := NewClient(srv.URL).NewDepositWatcher(1)
+	assert.NoError(t, err)
+	defer w.Close()
+	w.Watch("0x3d7e9dfbc58952fdacee2a5c69367c8478474d82")
+	w.Watch(addr)
+
+	// not advanced to deposit 5 until it is received
+	assert.Eventually(t, func() bool { return w.Cursor() == 4 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int64(4), w.Cursor())
+
+	select {
+	case deposit := <-w.Subscribe():
+		assert.Equal(t, schema.Deposit{
+			RawId: 5, EverHash: "0x5", AccId: addr, TokenTag: token.Tag, Symbol: token.Symbol, Timestamp: 1700000000005,
+			TargetChainType: "bsc", TargetChainTxHash: "0xa5",
+			Amount: big.NewInt(1234567), Decimals: 6, TargetAmount: big.NewInt(1234567000000000000), TargetDecimals: 18,
+		}, deposit)
+	case <-time.After(5 * time.Second):
+		t.Fatal("deposit timeout")
+	}
+
+	// token of target chain without cross chain info, listed after watcher started
+	arToken := schema.TokenInfo{
+		Tag: "arweave-ar-AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", Symbol: "AR", ChainType: "arweave", ChainID: "0",
+		ID: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA", Decimals: 12,
+	}
+	srv.update(func(p *testPayServer) {
+		p.tokens = append(p.tokens, arToken)
+		p.txResps = append(p.txResps, schema.TxResponse{
+			RawId: 6, Action: schema.TxActionMint, TokenSymbol: arToken.Symbol, ChainType: arToken.ChainType, TokenID: arToken.ID,
+			From: other, To: addr, Amount: "10", Data: `{"id":"ylP7Gu7vmImDRMX3K4K1TqtVT18ku0jC9gKS-XuTau8"}`, EverHash: "0x6",
+		})
+	})
+	select {
+	case deposit := <-w.Subscribe():
+		assert.Equal(t, arToken.Tag, deposit.TokenTag)
+		assert.Equal(t, "arweave", deposit.TargetChainType)
+		assert.Equal(t, "ylP7Gu7vmImDRMX3K4K1TqtVT18ku0jC9gKS-XuTau8", deposit.TargetChainTxHash)
+		assert.Equal(t, big.NewInt(10), deposit.TargetAmount)
+		assert.Equal(t, 12, deposit.TargetDecimals)
+	case <-time.After(5 * time.Second):
+		t.Fatal("deposit timeout")
+	}
+	assert.Eventually(t, func() bool { return w.Cursor() == 6 }, time.Second, 10*time.Millisecond)
+
+	w.Unwatch(addr)
+	srv.update(func(p *testPayServer) {
+		p.txResps = append(p.txResps, mint(7, other, addr, "7", `{"hash":"0xa7"}`))
+	})
+	select {
+	case deposit := <-w.Subscribe():
+		t.Fatal("deposit of unwatched address", deposit.EverHash)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
@@ -0,0 +1,69 @@
+package sdk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/everVision/everpay-kits/schema"
+)
+
+const (
+	ExportFormatJSONL = "jsonl"
+	ExportFormatCSV   = "csv"
+)
+
+var exportCSVHeader = []string{"rawId", "id", "everHash", "status", "internalStatus", "timestamp", "action",
+	"tokenSymbol", "tokenID", "chainType", "chainID", "from", "to", "amount", "fee", "feeRecipient", "nonce",
+	"version", "data", "sig", "targetChainTxHash"}
+
+// ExportAccTxs dump the complete tx history of accid to w in ASC order
+// format: jsonl or csv
+func (c *Client) ExportAccTxs(accid, format string, w io.Writer) (num int, err error) {
+	it := c.NewTxIterator(0, OrderByAsc, schema.TxOpts{Address: accid})
+	return ExportTxs(it, format, w)
+}
+
+// ExportTxs dump all txs of the iterator to w
+func ExportTxs(it *TxIterator, format string, w io.Writer) (num int, err error) {
+	switch format {
+	case ExportFormatJSONL:
+		enc := json.NewEncoder(w)
+		for it.Next() {
+			if err = enc.Encode(it.Tx()); err != nil {
+				return
+			}
+			num++
+		}
+	case ExportFormatCSV:
+		cw := csv.NewWriter(w)
+		if err = cw.Write(exportCSVHeader); err != nil {
+			return
+		}
+		for it.Next() {
+			if err = cw.Write(txToCSVRecord(it.Tx())); err != nil {
+				return
+			}
+			num++
+		}
+		cw.Flush()
+		if err = cw.Error(); err != nil {
+			return
+		}
+	default:
+		return 0, errors.New("not support this export format")
+	}
+	err = it.Err()
+	return
+}
+
+func txToCSVRecord(tx schema.TxResponse) []string {
+	return []string{
+		strconv.FormatInt(tx.RawId, 10), tx.ID, tx.EverHash, tx.Status, tx.InternalStatus,
+		strconv.FormatInt(tx.Timestamp, 10), tx.Action, tx.TokenSymbol, tx.TokenID, tx.ChainType, tx.ChainID,
+		tx.From, tx.To, tx.Amount, tx.Fee, tx.FeeRecipient, strconv.FormatInt(tx.Nonce, 10),
+		tx.Version, tx.Data, tx.Sig, tx.TargetChainTxHash,
+	}
+}
@@ -0,0 +1,163 @@
+package sdk
+
+import (
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+)
+
+const defaultFeeTTL = 1 * time.Minute
+
+type cachedFee struct {
+	fee       schema.TokenFee
+	updatedAt time.Time
+}
+
+// FeeEstimator cache token fees from everPay, the fee is paid by sender on top of tx amount
+type FeeEstimator struct {
+	client *Client
+	ttl    time.Duration
+
+	lock sync.RWMutex
+	fees map[string]cachedFee // tag -> fee
+}
+
+// NewFeeEstimator ttl: fee cache time, default 1 minute
+func NewFeeEstimator(c *Client, ttl time.Duration) *FeeEstimator {
+	if ttl <= 0 {
+		ttl = defaultFeeTTL
+	}
+	return &FeeEstimator{
+		client: c,
+		ttl:    ttl,
+		fees:   make(map[string]cachedFee),
+	}
+}
+
+// Refresh update all token fees
+func (f *FeeEstimator) Refresh() error {
+	fees, err := f.client.Fees()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, fee := range fees.Fees {
+		f.fees[fee.TokenTag] = cachedFee{fee: fee, updatedAt: now}
+	}
+	return nil
+}
+
+// TokenFee return cached fee, fetch from everPay if expired
+func (f *FeeEstimator) TokenFee(tokenTag string) (schema.TokenFee, time.Time, error) {
+	f.lock.RLock()
+	cf, ok := f.fees[tokenTag]
+	f.lock.RUnlock()
+	if ok && time.Since(cf.updatedAt) < f.ttl {
+		return cf.fee, cf.updatedAt, nil
+	}
+
+	fee, err := f.client.Fee(tokenTag)
+	if err != nil {
+		return schema.TokenFee{}, time.Time{}, err
+	}
+	cf = cachedFee{fee: fee.Fee, updatedAt: time.Now()}
+	f.lock.Lock()
+	f.fees[tokenTag] = cf
+	f.lock.Unlock()
+	return cf.fee, cf.updatedAt, nil
+}
+
+// Invalidate drop the cached fee of token, the next quote fetches the latest fee from everPay
+func (f *FeeEstimator) Invalidate(tokenTag string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.fees, tokenTag)
+}
+
+// Quote fee for action on token, targetChainType is required by burn
+func (f *FeeEstimator) Quote(action, tokenTag, targetChainType string) (schema.FeeQuote, error) {
+	quote := schema.FeeQuote{
+		TokenTag: tokenTag,
+		Action:   action,
+		Fee:      "0",
+	}
+	switch action {
+	case schema.TxActionTransfer, schema.TxActionBundle, schema.TxActionBurn:
+	default:
+		quote.ExpiresAt = time.Now().Add(f.ttl)
+		return quote, nil
+	}
+
+	tokFee, updatedAt, err := f.TokenFee(tokenTag)
+	if err != nil {
+		return schema.FeeQuote{}, err
+	}
+	quote.ExpiresAt = updatedAt.Add(f.ttl)
+	switch action {
+	case schema.TxActionTransfer:
+		quote.Fee = tokFee.TransferFee
+	case schema.TxActionBundle:
+		quote.Fee = tokFee.BundleFee
+	case schema.TxActionBurn:
+		fee, ok := tokFee.BurnFeeMap[targetChainType]
+		if !ok {
+			return schema.FeeQuote{}, schema.ERR_BURN_FEE_NOT_EXIST
+		}
+		quote.TargetChainType = targetChainType
+		quote.Fee = fee
+	}
+	return quote, nil
+}
+
+// GrossUp return the tx amount to make the receiver get exactly netAmount, and the total amount debited from sender.
+// transfer, bundle and burn fee is paid by sender on top of amount, other actions are free
+func (f *FeeEstimator) GrossUp(quote schema.FeeQuote, netAmount *big.Int) (amount, total *big.Int, err error) {
+	if netAmount == nil || netAmount.Sign() < 0 {
+		return nil, nil, schema.ERR_INVALID_AMOUNT
+	}
+	fee, ok := new(big.Int).SetString(quote.Fee, 10)
+	if !ok || fee.Sign() < 0 {
+		return nil, nil, schema.ERR_INVALID_FEE
+	}
+	switch quote.Action {
+	case schema.TxActionTransfer, schema.TxActionBundle, schema.TxActionBurn:
+	case "":
+		return nil, nil, schema.ERR_FEE_QUOTE_MISMATCH
+	default:
+		if fee.Sign() != 0 {
+			return nil, nil, schema.ERR_INVALID_FEE
+		}
+	}
+	amount = new(big.Int).Set(netAmount)
+	total = new(big.Int).Add(amount, fee)
+	return
+}
+
+// CheckBalance check the balance of accid is enough for amount and fee
+func (f *FeeEstimator) CheckBalance(accid string, quote schema.FeeQuote, amount *big.Int) error {
+	_, total, err := f.GrossUp(quote, amount)
+	if err != nil {
+		return err
+	}
+	bal, err := f.client.Balance(quote.TokenTag, accid)
+	if err != nil {
+		return err
+	}
+	balance, ok := new(big.Int).SetString(bal.Balance.Amount, 10)
+	if !ok {
+		return schema.ERR_INVALID_AMOUNT
+	}
+	if balance.Cmp(total) < 0 {
+		return schema.ERR_INSUFFICIENT_BALANCE
+	}
+	return nil
+}
+
+// isFeeErr everPay rejects tx which fee is not the latest fee
+func isFeeErr(err error) bool {
+	return err != nil && err.Error() == schema.ERR_INVALID_FEE.Error()
+}
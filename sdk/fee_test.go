@@ -0,0 +1,141 @@
+package sdk
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTokenFee(transferFee, bundleFee, burnFee string) schema.TokenFee {
+	return schema.TokenFee{
+		TokenTag:    testTokenTag,
+		TransferFee: transferFee,
+		BundleFee:   bundleFee,
+		BurnFeeMap:  map[string]string{schema.OracleEthChainType: burnFee},
+	}
+}
+
+func TestFeeEstimator_Quote(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.fees[testTokenTag] = testTokenFee("1", "2", "3")
+	f := NewFeeEstimator(NewClient(srv.URL), time.Minute)
+
+	quote, err := f.Quote(schema.TxActionTransfer, testTokenTag, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", quote.Fee)
+	assert.Equal(t, schema.TxActionTransfer, quote.Action)
+	assert.False(t, quote.Expired())
+	assert.WithinDuration(t, time.Now().Add(time.Minute), quote.ExpiresAt, time.Second)
+	quote, err = f.Quote(schema.TxActionBundle, testTokenTag, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", quote.Fee)
+	quote, err = f.Quote(schema.TxActionBurn, testTokenTag, schema.OracleEthChainType)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", quote.Fee)
+	assert.Equal(t, schema.OracleEthChainType, quote.TargetChainType)
+	_, err = f.Quote(schema.TxActionBurn, testTokenTag, schema.OracleBscChainType)
+	assert.Equal(t, schema.ERR_BURN_FEE_NOT_EXIST, err)
+	// free action
+	quote, err = f.Quote(schema.TxActionMint, testTokenTag, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "0", quote.Fee)
+	assert.Equal(t, 1, srv.feeCount())
+
+	// cached until invalidated
+	srv.update(func(p *testPayServer) { p.fees[testTokenTag] = testTokenFee("10", "20", "30") })
+	quote, err = f.Quote(schema.TxActionTransfer, testTokenTag, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", quote.Fee)
+	f.Invalidate(testTokenTag)
+	quote, err = f.Quote(schema.TxActionTransfer, testTokenTag, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "10", quote.Fee)
+	assert.Equal(t, 2, srv.feeCount())
+
+	// expired
+	f = NewFeeEstimator(NewClient(srv.URL), 10*time.Millisecond)
+	assert.NoError(t, f.Refresh())
+	quote, err = f.Quote(schema.TxActionTransfer, testTokenTag, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "10", quote.Fee)
+	assert.Equal(t, 3, srv.feeCount())
+	time.Sleep(20 * time.Millisecond)
+	assert.True(t, quote.Expired())
+	srv.update(func(p *testPayServer) { p.fees[testTokenTag] = testTokenFee("100", "200", "300") })
+	quote, err = f.Quote(schema.TxActionTransfer, testTokenTag, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "100", quote.Fee)
+}
+
+func TestFeeEstimator_GrossUp(t *testing.T) {
+	f := NewFeeEstimator(nil, 0)
+	tests := []struct {
+		action string
+		fee    string
+		net    *big.Int
+		amount string
+		total  string
+		err    error
+	}{
+		{schema.TxActionTransfer, "5", big.NewInt(100), "100", "105", nil},
+		{schema.TxActionBundle, "5", big.NewInt(100), "100", "105", nil},
+		{schema.TxActionBurn, "7", big.NewInt(0), "0", "7", nil},
+		{schema.TxActionMint, "0", big.NewInt(100), "100", "100", nil},
+		{schema.TxActionMint, "5", big.NewInt(100), "", "", schema.ERR_INVALID_FEE},
+		{"", "5", big.NewInt(100), "", "", schema.ERR_FEE_QUOTE_MISMATCH},
+		{schema.TxActionTransfer, "0.5", big.NewInt(100), "", "", schema.ERR_INVALID_FEE},
+		{schema.TxActionTransfer, "-5", big.NewInt(100), "", "", schema.ERR_INVALID_FEE},
+		{schema.TxActionTransfer, "5", big.NewInt(-1), "", "", schema.ERR_INVALID_AMOUNT},
+		{schema.TxActionTransfer, "5", nil, "", "", schema.ERR_INVALID_AMOUNT},
+	}
+	for _, tt := range tests {
+		amount, total, err := f.GrossUp(schema.FeeQuote{TokenTag: testTokenTag, Action: tt.action, Fee: tt.fee}, tt.net)
+		assert.Equal(t, tt.err, err, tt.action, tt.fee)
+		if tt.err != nil {
+			continue
+		}
+		assert.Equal(t, tt.amount, amount.String())
+		assert.Equal(t, tt.total, total.String())
+	}
+}
+
+func TestFeeEstimator_CheckBalance(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.balances[testSignerAddr] = map[string]string{testTokenTag: "105"}
+	f := NewFeeEstimator(NewClient(srv.URL), 0)
+	quote := schema.FeeQuote{TokenTag: testTokenTag, Action: schema.TxActionTransfer, Fee: "5"}
+
+	assert.NoError(t, f.CheckBalance(testSignerAddr, quote, big.NewInt(100)))
+	assert.Equal(t, schema.ERR_INSUFFICIENT_BALANCE, f.CheckBalance(testSignerAddr, quote, big.NewInt(101)))
+	assert.Equal(t, schema.ERR_INSUFFICIENT_BALANCE, f.CheckBalance("0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", quote, big.NewInt(1)))
+}
+
+func TestSDK_WithdrawRefreshFee(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.fees[testTokenTag] = testTokenFee("1", "2", "3")
+	s := srv.newSDK(t)
+
+	quote, err := s.Fees.Quote(schema.TxActionBurn, testTokenTag, schema.OracleEthChainType)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", quote.Fee)
+
+	// fee changed in cache ttl
+	srv.update(func(p *testPayServer) { p.fees[testTokenTag] = testTokenFee("1", "2", "4") })
+	tx, err := s.Withdraw(testTokenTag, big.NewInt(100), schema.OracleEthChainType, testSignerAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, "4", tx.Fee)
+	assert.Equal(t, 1, len(srv.submitted()))
+
+	// pinned fee is not refreshed
+	srv.update(func(p *testPayServer) { p.fees[testTokenTag] = testTokenFee("1", "2", "5") })
+	quote.ExpiresAt = time.Now().Add(time.Minute)
+	_, err = s.WithdrawWithQuote(quote, big.NewInt(100), testSignerAddr)
+	assert.Equal(t, schema.ERR_INVALID_FEE.Error(), err.Error())
+	assert.Equal(t, 1, len(srv.submitted()))
+}
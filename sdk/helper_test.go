@@ -0,0 +1,349 @@
+package sdk
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/everFinance/goether"
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	testTokenTag   = "ethereum-usdt-0xdac17f958d2ee523a2206206994597c13d831ec7"
+	testSignerPrv  = "ad1dcf8f1c449e7af21a7b8341eba5f053055819fff9948f1251ea94a0184cae"
+	testSignerAddr = "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82"
+)
+
+// testPayServer mock everPay server shared by sdk tests,
+// submitted txs are recorded and admin txs are applied to the token state
+type testPayServer struct {
+	*httptest.Server
+
+	lock        sync.Mutex
+	payOwner    string
+	tokens      []schema.TokenInfo // listed in /info after the test token
+	extra       schema.Tns102Extra
+	whiteList   []string
+	blackList   []string
+	txs         []schema.Transaction // submitted by /tx
+	txResps     []schema.TxResponse  // served by /txs and /tx/:everHash, sorted by rawId ASC
+	accs        map[string]schema.RespAcc
+	fees        map[string]schema.TokenFee   // tag -> fee, txs with other fee are rejected by /tx
+	balances    map[string]map[string]string // accid -> tag -> amount
+	feeRequests int
+	ignoreOwner bool                                             // not apply transferOwner tx
+	submitErr   string                                           // /tx response body with status 500 if not empty
+	emailCodes  map[string]string                                // email -> code sent by /account/email/code
+	mailSig     func(timestamp int64, code, email string) string // sig of verified email code
+}
+
+func newTestPayServer() *testPayServer {
+	p := &testPayServer{
+		whiteList:  []string{},
+		blackList:  []string{},
+		accs:       map[string]schema.RespAcc{},
+		fees:       map[string]schema.TokenFee{},
+		balances:   map[string]map[string]string{},
+		emailCodes: map[string]string{},
+	}
+	p.Server = httptest.NewServer(http.HandlerFunc(p.serveHTTP))
+	return p
+}
+
+func newTestEccSigner(t *testing.T) *goether.Signer {
+	key, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	signer, err := goether.NewSigner(hex.EncodeToString(crypto.FromECDSA(key)))
+	assert.NoError(t, err)
+	return signer
+}
+
+// newSDK return sdk of the test signer connected to p
+func (p *testPayServer) newSDK(t *testing.T) *SDK {
+	signer, err := goether.NewSigner(testSignerPrv)
+	assert.NoError(t, err)
+	s, err := New(signer, p.URL)
+	assert.NoError(t, err)
+	return s
+}
+
+func (p *testPayServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	switch {
+	case r.URL.Path == "/info":
+		extra := p.extra
+		tokens := append([]schema.TokenInfo{{
+			Tag: testTokenTag, Symbol: "USDT", ChainType: "ethereum", ChainID: "1", TransferFee: "0",
+			ID: "0xdac17f958d2ee523a2206206994597c13d831ec7", TNS102Extra: &extra,
+		}}, p.tokens...)
+		json.NewEncoder(w).Encode(schema.Info{Owner: p.payOwner, TokenList: tokens})
+	case strings.HasPrefix(r.URL.Path, "/white_list/"):
+		json.NewEncoder(w).Encode(p.whiteList)
+	case strings.HasPrefix(r.URL.Path, "/black_list/"):
+		json.NewEncoder(w).Encode(p.blackList)
+	case r.URL.Path == "/account/email/code":
+		req := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&req)
+		p.emailCodes[req["email"]] = "123456"
+		w.Write([]byte(`{"status":"ok"}`))
+	case r.URL.Path == "/account/email/verify":
+		req := map[string]string{}
+		json.NewDecoder(r.Body).Decode(&req)
+		code, ok := p.emailCodes[req["email"]]
+		if !ok || code != req["code"] {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"err_invalid_email_code"}`))
+			return
+		}
+		delete(p.emailCodes, req["email"])
+		timestamp := time.Now().Unix()
+		json.NewEncoder(w).Encode(schema.RespRegister{Sig: p.mailSig(timestamp, code, req["email"]), Timestamp: timestamp})
+	case strings.HasPrefix(r.URL.Path, "/account/"):
+		acc, ok := p.accs[strings.TrimPrefix(r.URL.Path, "/account/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"err_not_found"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(acc)
+	case r.URL.Path == "/txs":
+		p.serveTxs(w, r)
+	case strings.HasPrefix(r.URL.Path, "/tx/"):
+		everHash := strings.TrimPrefix(r.URL.Path, "/tx/")
+		for i := range p.txResps {
+			if p.txResps[i].EverHash == everHash {
+				json.NewEncoder(w).Encode(schema.Tx{Tx: &p.txResps[i]})
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"err_not_found"}`))
+	case strings.HasPrefix(r.URL.Path, "/balance/"):
+		ss := strings.Split(strings.TrimPrefix(r.URL.Path, "/balance/"), "/")
+		amount, ok := p.balances[ss[1]][ss[0]]
+		if !ok {
+			amount = "0"
+		}
+		json.NewEncoder(w).Encode(schema.AccBalance{AccId: ss[1], Balance: schema.Balance{Tag: ss[0], Amount: amount}})
+	case strings.HasPrefix(r.URL.Path, "/balances/"):
+		accid := strings.TrimPrefix(r.URL.Path, "/balances/")
+		bals := schema.AccBalances{AccId: accid, Balances: []schema.Balance{}}
+		for tag, amount := range p.balances[accid] {
+			bals.Balances = append(bals.Balances, schema.Balance{Tag: tag, Amount: amount})
+		}
+		json.NewEncoder(w).Encode(bals)
+	case strings.HasPrefix(r.URL.Path, "/fee/"):
+		p.feeRequests++
+		json.NewEncoder(w).Encode(schema.Fee{Fee: p.fees[strings.TrimPrefix(r.URL.Path, "/fee/")]})
+	case r.URL.Path == "/fees":
+		p.feeRequests++
+		fees := schema.Fees{Fees: []schema.TokenFee{}}
+		for _, fee := range p.fees {
+			fees.Fees = append(fees.Fees, fee)
+		}
+		json.NewEncoder(w).Encode(fees)
+	case r.URL.Path == "/tx":
+		tx := schema.Transaction{}
+		json.NewDecoder(r.Body).Decode(&tx)
+		if p.submitErr != "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(p.submitErr))
+			return
+		}
+		if !p.checkFee(tx) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"err_invalid_fee"}`))
+			return
+		}
+		p.txs = append(p.txs, tx)
+		p.apply(tx)
+		w.Write([]byte(`{"status":"ok"}`))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (p *testPayServer) serveTxs(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cursor, _ := strconv.ParseInt(q.Get("cursor"), 10, 64)
+	count, _ := strconv.Atoi(q.Get("count"))
+	if count <= 0 {
+		count = 10
+	}
+	desc := strings.ToUpper(q.Get("order")) == OrderByDesc
+
+	result := make([]schema.TxResponse, 0)
+	all := p.txResps
+	for i := range all {
+		tx := all[i]
+		if desc {
+			tx = all[len(all)-1-i]
+		}
+		if cursor > 0 && ((!desc && tx.RawId <= cursor) || (desc && tx.RawId >= cursor)) {
+			continue
+		}
+		if addr := q.Get("address"); addr != "" && !strings.EqualFold(tx.From, addr) && !strings.EqualFold(tx.To, addr) {
+			continue
+		}
+		if action := q.Get("action"); action != "" && tx.Action != action {
+			continue
+		}
+		result = append(result, tx)
+	}
+	hasNextPage := len(result) > count
+	if hasNextPage {
+		result = result[:count]
+	}
+	json.NewEncoder(w).Encode(schema.Txs{Txs: result, HasNextPage: hasNextPage})
+}
+
+func (p *testPayServer) checkFee(tx schema.Transaction) bool {
+	fee, ok := p.fees[tx.Tag()]
+	if !ok {
+		return true
+	}
+	switch tx.Action {
+	case schema.TxActionTransfer:
+		return tx.Fee == fee.TransferFee
+	case schema.TxActionBundle:
+		return tx.Fee == fee.BundleFee
+	case schema.TxActionBurn:
+		return tx.Fee == fee.BurnFeeMap[gjson.Get(tx.Data, "targetChainType").String()]
+	}
+	return true
+}
+
+func (p *testPayServer) apply(tx schema.Transaction) {
+	data := gjson.Parse(tx.Data)
+	remove := func(list []string, ids []gjson.Result) []string {
+		res := make([]string, 0)
+		for _, v := range list {
+			found := false
+			for _, id := range ids {
+				found = found || strings.EqualFold(id.String(), v)
+			}
+			if !found {
+				res = append(res, v)
+			}
+		}
+		return res
+	}
+	switch tx.Action {
+	case schema.TxActionRegister:
+		p.accs[tx.From] = schema.RespAcc{
+			Id:           tx.From,
+			Type:         schema.AccountTypeEverId,
+			PublicType:   map[string]string{"0": data.Get("publicType").String()},
+			PublicValues: map[string]string{"0": data.Get("public").String()},
+		}
+	case schema.TxActionSet:
+		acc, ok := p.accs[tx.From]
+		if !ok {
+			return
+		}
+		switch data.Get("operate").String() {
+		case schema.SetOperateAddPublic:
+			id := 0
+			for acc.PublicValues[strconv.Itoa(id)] != "" {
+				id++
+			}
+			acc.PublicType[strconv.Itoa(id)] = data.Get("publicType").String()
+			acc.PublicValues[strconv.Itoa(id)] = data.Get("public").String()
+		case schema.SetOperateRemovePublic:
+			delete(acc.PublicType, data.Get("publicId").String())
+			delete(acc.PublicValues, data.Get("publicId").String())
+		}
+	case schema.TxActionAddWhiteList:
+		for _, id := range data.Get("whiteList").Array() {
+			p.whiteList = append(p.whiteList, id.String())
+		}
+	case schema.TxActionRemoveWhiteList:
+		p.whiteList = remove(p.whiteList, data.Get("whiteList").Array())
+	case schema.TxActionAddBlackList:
+		for _, id := range data.Get("blackList").Array() {
+			p.blackList = append(p.blackList, id.String())
+		}
+	case schema.TxActionRemoveBlackList:
+		p.blackList = remove(p.blackList, data.Get("blackList").Array())
+	case schema.TxActionPauseWhiteList:
+		p.extra.PauseWhiteList = data.Get("pause").Bool()
+	case schema.TxActionPauseBlackList:
+		p.extra.PauseBlackList = data.Get("pause").Bool()
+	case schema.TxActionPause:
+		p.extra.Pause = data.Get("pause").Bool()
+	case schema.TxActionTransferOwner:
+		if !p.ignoreOwner {
+			p.extra.Owner = tx.To
+		}
+	}
+}
+
+// update change the mock state with lock
+func (p *testPayServer) update(f func(p *testPayServer)) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	f(p)
+}
+
+// submitted return a copy of the txs submitted to p
+func (p *testPayServer) submitted() []schema.Transaction {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return append([]schema.Transaction{}, p.txs...)
+}
+
+func (p *testPayServer) feeCount() int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.feeRequests
+}
+
+func (p *testPayServer) owner() string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.extra.Owner
+}
+
+func genTestTxs(num int, addr string) []schema.TxResponse {
+	txs := make([]schema.TxResponse, 0, num)
+	for i := 1; i <= num; i++ {
+		txs = append(txs, schema.TxResponse{
+			RawId:    int64(i),
+			Action:   schema.TxActionTransfer,
+			From:     addr,
+			To:       "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223",
+			Amount:   strconv.Itoa(i),
+			EverHash: "0x" + strconv.Itoa(i),
+		})
+	}
+	return txs
+}
+
+type testAuditSink struct {
+	lock    sync.Mutex
+	records []schema.AuditRecord
+}
+
+func (s *testAuditSink) Append(record schema.AuditRecord) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
+
+func mustSignMsg(t *testing.T, signer interface{}, msg string) string {
+	sig, err := SignMsg(signer, msg)
+	assert.NoError(t, err)
+	return sig
+}
@@ -0,0 +1,264 @@
+package sdk
+
+import (
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultInvoiceCheckInterval = 30 * time.Second
+	defaultInvoiceRetryInterval = time.Second
+)
+
+// InvoiceManager create invoices for merchant accId and match the incoming transfer txs by "memo" in tx data
+// tx time is tx.Timestamp(ms) set by everPay, a payment after invoice expired is a late payment and the invoice keeps expired
+type InvoiceManager struct {
+	client  *Client
+	accId   string
+	storage InvoiceStorage
+
+	lock     sync.Mutex // protect invoice read-modify-write
+	sub      *SubscribeTx
+	events   chan schema.InvoiceEvent
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+// NewInvoiceManager subscribe transfer txs to accId from the cursor saved in storage
+func (c *Client) NewInvoiceManager(accId string, storage InvoiceStorage) (*InvoiceManager, error) {
+	if storage == nil {
+		storage = NewMemoryInvoiceStorage()
+	}
+	cursor, err := storage.GetCursor()
+	if err != nil {
+		return nil, err
+	}
+	if cursor == 0 {
+		// start from the latest tx of accId
+		txs, err := c.Txs(0, OrderByDesc, 1, schema.TxOpts{Address: accId})
+		if err != nil {
+			return nil, err
+		}
+		cursor = txs.NextCursor
+	}
+
+	m := &InvoiceManager{
+		client:  c,
+		accId:   accId,
+		storage: storage,
+		events:  make(chan schema.InvoiceEvent),
+		quit:    make(chan struct{}),
+	}
+	m.sub = c.SubscribeTxs(schema.FilterQuery{
+		StartCursor: cursor,
+		To:          accId,
+		Action:      schema.TxActionTransfer,
+	})
+	go m.run()
+	return m, nil
+}
+
+// CreateInvoice create a pending invoice, ttl: invoice valid time
+func (m *InvoiceManager) CreateInvoice(tokenTag string, amount *big.Int, ttl time.Duration) (schema.Invoice, error) {
+	if amount == nil || amount.Sign() <= 0 {
+		return schema.Invoice{}, schema.ERR_INVALID_AMOUNT
+	}
+	now := time.Now()
+	inv := schema.Invoice{
+		ID:        uuid.NewString(),
+		AccId:     m.accId,
+		TokenTag:  tokenTag,
+		Amount:    amount.String(),
+		Paid:      "0",
+		Status:    schema.InvoiceStatusPending,
+		CreatedAt: now.Unix(),
+		ExpiredAt: now.Add(ttl).Unix(),
+		UpdatedAt: now.Unix(),
+	}
+	return inv, m.storage.SaveInvoice(inv)
+}
+
+func (m *InvoiceManager) Invoice(id string) (schema.Invoice, error) {
+	return m.storage.GetInvoice(id)
+}
+
+// PaymentRequest return the payment request of invoice, decimals: token decimals
+func (m *InvoiceManager) PaymentRequest(inv schema.Invoice, decimals int) schema.PaymentRequest {
+	amount, _ := new(big.Int).SetString(inv.Amount, 10)
+	return schema.PaymentRequest{
+		Recipient:  inv.AccId,
+		TokenTag:   inv.TokenTag,
+		Amount:     utils.FormatAmount(amount, decimals),
+		Memo:       inv.ID,
+		Expiration: inv.ExpiredAt,
+	}
+}
+
+// Events return invoice status changes, a slow consumer blocks the tx matching
+func (m *InvoiceManager) Events() <-chan schema.InvoiceEvent {
+	return m.events
+}
+
+func (m *InvoiceManager) Close() {
+	m.quitOnce.Do(func() {
+		close(m.quit)
+		m.sub.Unsubscribe()
+	})
+}
+
+func (m *InvoiceManager) run() {
+	ticker := time.NewTicker(defaultInvoiceCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case tx := <-m.sub.Subscribe():
+			if !m.handle(tx) {
+				return
+			}
+		case <-ticker.C:
+			if err := m.ExpireInvoices(); err != nil {
+				log.Error("expire invoices failed", "err", err)
+			}
+		case <-m.quit:
+			return
+		}
+	}
+}
+
+// handle retry HandleTx until succeed and save tx as cursor, return false if closed
+func (m *InvoiceManager) handle(tx schema.TxResponse) bool {
+	for {
+		err := m.HandleTx(tx)
+		if err == nil {
+			break
+		}
+		if err == schema.ERR_INVALID_AMOUNT {
+			// never succeed, skip it
+			log.Error("invalid invoice tx", "everHash", tx.EverHash, "err", err)
+			break
+		}
+		log.Error("handle invoice tx failed, retry", "everHash", tx.EverHash, "err", err)
+		select {
+		case <-time.After(defaultInvoiceRetryInterval):
+		case <-m.quit:
+			return false
+		}
+	}
+	if err := m.storage.SaveCursor(tx.RawId); err != nil {
+		log.Error("save invoice cursor failed", "err", err)
+	}
+	return true
+}
+
+// HandleTx match tx to invoice, txs not belong to any invoice are ignored
+func (m *InvoiceManager) HandleTx(tx schema.TxResponse) error {
+	if tx.Action != schema.TxActionTransfer {
+		return nil
+	}
+	ref := gjson.Get(tx.Data, "memo").String()
+	if ref == "" {
+		return nil
+	}
+
+	m.lock.Lock()
+	inv, err := m.storage.GetInvoice(ref)
+	if err == schema.ERR_INVOICE_NOT_EXIST {
+		m.lock.Unlock()
+		return nil
+	}
+	if err != nil {
+		m.lock.Unlock()
+		return err
+	}
+	if utils.FormatAccId(tx.To) != utils.FormatAccId(inv.AccId) || !strings.EqualFold(tx.Tag(), inv.TokenTag) {
+		m.lock.Unlock()
+		log.Warn("tx not match invoice", "everHash", tx.EverHash, "invoice", inv.ID)
+		return nil
+	}
+	if schema.ContainsStr(strings.Split(inv.EverHashes, ","), tx.EverHash, true) {
+		m.lock.Unlock()
+		return nil
+	}
+
+	txAmount, ok := new(big.Int).SetString(tx.Amount, 10)
+	if !ok {
+		m.lock.Unlock()
+		return schema.ERR_INVALID_AMOUNT
+	}
+	amount, _ := new(big.Int).SetString(inv.Amount, 10)
+	paid, ok := new(big.Int).SetString(inv.Paid, 10)
+	if !ok {
+		paid = new(big.Int)
+	}
+	paid.Add(paid, txAmount)
+
+	inv.Paid = paid.String()
+	if inv.EverHashes == "" {
+		inv.EverHashes = tx.EverHash
+	} else {
+		inv.EverHashes += "," + tx.EverHash
+	}
+	switch {
+	case inv.Status == schema.InvoiceStatusExpired || tx.Timestamp/1000 > inv.ExpiredAt:
+		inv.Status = schema.InvoiceStatusExpired
+	case paid.Cmp(amount) == 0:
+		inv.Status = schema.InvoiceStatusPaid
+	case paid.Cmp(amount) < 0:
+		inv.Status = schema.InvoiceStatusUnderpaid
+	default:
+		inv.Status = schema.InvoiceStatusOverpaid
+	}
+	inv.UpdatedAt = time.Now().Unix()
+	err = m.storage.SaveInvoice(inv)
+	m.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	m.emit(schema.InvoiceEvent{Type: inv.Status, Invoice: inv, Tx: &tx})
+	return nil
+}
+
+// ExpireInvoices expire the open invoices after ExpiredAt
+func (m *InvoiceManager) ExpireInvoices() error {
+	invs, err := m.storage.OpenInvoices()
+	if err != nil {
+		return err
+	}
+	now := time.Now().Unix()
+	for _, inv := range invs {
+		if now <= inv.ExpiredAt {
+			continue
+		}
+		m.lock.Lock()
+		// reload, invoice may be paid after OpenInvoices
+		inv, err = m.storage.GetInvoice(inv.ID)
+		if err != nil || (inv.Status != schema.InvoiceStatusPending && inv.Status != schema.InvoiceStatusUnderpaid) {
+			m.lock.Unlock()
+			continue
+		}
+		inv.Status = schema.InvoiceStatusExpired
+		inv.UpdatedAt = now
+		err = m.storage.SaveInvoice(inv)
+		m.lock.Unlock()
+		if err != nil {
+			return err
+		}
+		m.emit(schema.InvoiceEvent{Type: inv.Status, Invoice: inv})
+	}
+	return nil
+}
+
+func (m *InvoiceManager) emit(e schema.InvoiceEvent) {
+	select {
+	case m.events <- e:
+	case <-m.quit:
+	}
+}
@@ -0,0 +1,73 @@
+package sdk
+
+import (
+	"sync"
+
+	"github.com/everVision/everpay-kits/schema"
+)
+
+// InvoiceStorage persist invoices and the tx cursor of InvoiceManager
+type InvoiceStorage interface {
+	// SaveInvoice insert or update invoice
+	SaveInvoice(inv schema.Invoice) error
+	// GetInvoice return schema.ERR_INVOICE_NOT_EXIST if not found
+	GetInvoice(id string) (schema.Invoice, error)
+	// OpenInvoices return pending and underpaid invoices
+	OpenInvoices() ([]schema.Invoice, error)
+	GetCursor() (int64, error)
+	SaveCursor(cursor int64) error
+}
+
+type MemoryInvoiceStorage struct {
+	lock     sync.RWMutex
+	invoices map[string]schema.Invoice
+	cursor   int64
+}
+
+func NewMemoryInvoiceStorage() *MemoryInvoiceStorage {
+	return &MemoryInvoiceStorage{
+		invoices: make(map[string]schema.Invoice),
+	}
+}
+
+func (m *MemoryInvoiceStorage) SaveInvoice(inv schema.Invoice) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.invoices[inv.ID] = inv
+	return nil
+}
+
+func (m *MemoryInvoiceStorage) GetInvoice(id string) (schema.Invoice, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	inv, ok := m.invoices[id]
+	if !ok {
+		return schema.Invoice{}, schema.ERR_INVOICE_NOT_EXIST
+	}
+	return inv, nil
+}
+
+func (m *MemoryInvoiceStorage) OpenInvoices() ([]schema.Invoice, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	invs := make([]schema.Invoice, 0)
+	for _, inv := range m.invoices {
+		if inv.Status == schema.InvoiceStatusPending || inv.Status == schema.InvoiceStatusUnderpaid {
+			invs = append(invs, inv)
+		}
+	}
+	return invs, nil
+}
+
+func (m *MemoryInvoiceStorage) GetCursor() (int64, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.cursor, nil
+}
+
+func (m *MemoryInvoiceStorage) SaveCursor(cursor int64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.cursor = cursor
+	return nil
+}
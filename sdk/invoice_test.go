@@ -0,0 +1,157 @@
+package sdk
+
+import (
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvoiceManager(t *testing.T) {
+	merchant := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	srv := newTestPayServer()
+	defer srv.Close()
+
+	m, err := NewClient(srv.URL).NewInvoiceManager(merchant, nil)
+	assert.NoError(t, err)
+	defer m.Close()
+	events := make(chan schema.InvoiceEvent, 10)
+	go func() {
+		for e := range m.Events() {
+			events <- e
+		}
+	}()
+
+	inv, err := m.CreateInvoice(testTokenTag, big.NewInt(100), time.Hour)
+	assert.NoError(t, err)
+	payTx := func(everHash, amount string) schema.TxResponse {
+		now := time.Now().UnixNano() / 1e6
+		return schema.TxResponse{
+			Action:      schema.TxActionTransfer,
+			TokenSymbol: "USDT",
+			ChainType:   schema.ChainTypeEth,
+			TokenID:     "0xdac17f958d2ee523a2206206994597c13d831ec7",
+			From:        "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82",
+			To:          merchant,
+			Amount:      amount,
+			Nonce:       now,
+			Timestamp:   now,
+			Data:        `{"memo":"` + inv.ID + `"}`,
+			EverHash:    everHash,
+		}
+	}
+
+	assert.NoError(t, m.HandleTx(payTx("0x01", "60")))
+	e := <-events
+	assert.Equal(t, schema.InvoiceStatusUnderpaid, e.Type)
+	assert.Equal(t, "60", e.Invoice.Paid)
+
+	// duplicated tx is ignored
+	assert.NoError(t, m.HandleTx(payTx("0x01", "60")))
+	assert.NoError(t, m.HandleTx(payTx("0x02", "40")))
+	e = <-events
+	assert.Equal(t, schema.InvoiceStatusPaid, e.Type)
+	assert.Equal(t, "0x01,0x02", e.Invoice.EverHashes)
+
+	// expired invoice
+	inv, err = m.CreateInvoice(testTokenTag, big.NewInt(100), -time.Minute)
+	assert.NoError(t, err)
+	assert.NoError(t, m.ExpireInvoices())
+	e = <-events
+	assert.Equal(t, schema.InvoiceStatusExpired, e.Type)
+	assert.Equal(t, inv.ID, e.Invoice.ID)
+	assert.Nil(t, e.Tx)
+
+	// late payment
+	assert.NoError(t, m.HandleTx(payTx("0x03", "100")))
+	e = <-events
+	assert.Equal(t, schema.InvoiceStatusExpired, e.Type)
+	assert.Equal(t, "100", e.Invoice.Paid)
+
+	// payer controlled nonce is not the payment time
+	inv, err = m.CreateInvoice(testTokenTag, big.NewInt(100), time.Minute)
+	assert.NoError(t, err)
+	tx := payTx("0x04", "100")
+	tx.Timestamp = time.Now().Add(2*time.Minute).UnixNano() / 1e6
+	assert.NoError(t, m.HandleTx(tx))
+	e = <-events
+	assert.Equal(t, schema.InvoiceStatusExpired, e.Type)
+	inv, err = m.CreateInvoice(testTokenTag, big.NewInt(100), time.Minute)
+	assert.NoError(t, err)
+	tx = payTx("0x05", "100")
+	tx.Nonce = time.Now().Add(2*time.Minute).UnixNano() / 1e6
+	assert.NoError(t, m.HandleTx(tx))
+	e = <-events
+	assert.Equal(t, schema.InvoiceStatusPaid, e.Type)
+
+	// receiver address in other case
+	inv, err = m.CreateInvoice(testTokenTag, big.NewInt(100), time.Minute)
+	assert.NoError(t, err)
+	tx = payTx("0x06", "100")
+	tx.To = "0x4002ed1a1410af1b4930cf6c479ae373debd6223"
+	assert.NoError(t, m.HandleTx(tx))
+	e = <-events
+	assert.Equal(t, schema.InvoiceStatusPaid, e.Type)
+	assert.Equal(t, inv.ID, e.Invoice.ID)
+}
+
+// failInvoiceStorage GetInvoice fails the first fails times
+type failInvoiceStorage struct {
+	*MemoryInvoiceStorage
+	lock  sync.Mutex
+	fails int
+}
+
+func (s *failInvoiceStorage) GetInvoice(id string) (schema.Invoice, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.fails > 0 {
+		s.fails--
+		return schema.Invoice{}, errors.New("storage unavailable")
+	}
+	return s.MemoryInvoiceStorage.GetInvoice(id)
+}
+
+func TestInvoiceManager_Retry(t *testing.T) {
+	merchant := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	srv := newTestPayServer()
+	defer srv.Close()
+	storage := &failInvoiceStorage{MemoryInvoiceStorage: NewMemoryInvoiceStorage()}
+
+	m, err := NewClient(srv.URL).NewInvoiceManager(merchant, storage)
+	assert.NoError(t, err)
+	defer m.Close()
+	inv, err := m.CreateInvoice(testTokenTag, big.NewInt(100), time.Hour)
+	assert.NoError(t, err)
+
+	storage.lock.Lock()
+	storage.fails = 1
+	storage.lock.Unlock()
+	now := time.Now().UnixNano() / 1e6
+	srv.update(func(p *testPayServer) {
+		p.txResps = append(p.txResps, schema.TxResponse{
+			RawId: 1, Action: schema.TxActionTransfer, TokenSymbol: "USDT", ChainType: schema.ChainTypeEth,
+			TokenID: "0xdac17f958d2ee523a2206206994597c13d831ec7", From: "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82",
+			To: merchant, Amount: "100", Timestamp: now, Data: `{"memo":"` + inv.ID + `"}`, EverHash: "0x01",
+		})
+	})
+
+	select {
+	case e := <-m.Events():
+		assert.Equal(t, schema.InvoiceStatusPaid, e.Type)
+		assert.Equal(t, inv.ID, e.Invoice.ID)
+	case <-time.After(5 * time.Second):
+		t.Fatal("invoice event timeout")
+	}
+	storage.lock.Lock()
+	assert.Equal(t, 0, storage.fails)
+	storage.lock.Unlock()
+	assert.Eventually(t, func() bool {
+		cursor, err := storage.GetCursor()
+		return err == nil && cursor == 1
+	}, time.Second, 10*time.Millisecond)
+}
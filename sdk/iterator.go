@@ -0,0 +1,117 @@
+package sdk
+
+import (
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+)
+
+const (
+	OrderByAsc  = "ASC"
+	OrderByDesc = "DESC"
+
+	defaultIteratorLimit    = 100
+	defaultIteratorInterval = 200 * time.Millisecond
+)
+
+// TxIterator walk all pages of Client.Txs
+//
+//	it := client.NewTxIterator(0, sdk.OrderByAsc, schema.TxOpts{Address: accid})
+//	for it.Next() {
+//		tx := it.Tx()
+//	}
+//	if err := it.Err(); err != nil {}
+type TxIterator struct {
+	client   *Client
+	opts     schema.TxOpts
+	orderBy  string
+	limit    int
+	interval time.Duration // min interval between two requests
+
+	cursor      int64
+	txs         []schema.TxResponse
+	idx         int
+	fetched     bool
+	hasNextPage bool
+	lastReq     time.Time
+	err         error
+}
+
+// NewTxIterator startCursor: rawId of tx, not included in result; 0 means from the first(ASC) or latest(DESC) tx
+// opts client side filters are applied on every page
+func (c *Client) NewTxIterator(startCursor int64, orderBy string, opts schema.TxOpts) *TxIterator {
+	return &TxIterator{
+		client:   c,
+		opts:     opts,
+		orderBy:  orderBy,
+		limit:    defaultIteratorLimit,
+		interval: defaultIteratorInterval,
+		cursor:   startCursor,
+	}
+}
+
+// SetPageLimit set the count of txs per request
+func (it *TxIterator) SetPageLimit(limit int) {
+	it.limit = limit
+}
+
+// SetRateLimit set the min interval between two requests
+func (it *TxIterator) SetRateLimit(interval time.Duration) {
+	it.interval = interval
+}
+
+// Next return false when all txs walked or an error happened
+func (it *TxIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.idx++
+	if it.idx < len(it.txs) {
+		return true
+	}
+
+	if it.fetched && !it.hasNextPage {
+		return false
+	}
+	if !it.fetchPage() {
+		return false
+	}
+	return len(it.txs) > 0
+}
+
+// Tx return the current tx, must be called after Next return true
+func (it *TxIterator) Tx() schema.TxResponse {
+	return it.txs[it.idx]
+}
+
+func (it *TxIterator) Err() error {
+	return it.err
+}
+
+func (it *TxIterator) fetchPage() bool {
+	for {
+		if wait := it.interval - time.Since(it.lastReq); wait > 0 {
+			time.Sleep(wait)
+		}
+		it.lastReq = time.Now()
+
+		txs, err := it.client.Txs(it.cursor, it.orderBy, it.limit, it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.fetched = true
+		it.txs = txs.Txs
+		it.idx = 0
+		// cursor not moved means no more txs on server
+		moved := txs.NextCursor > 0 && txs.NextCursor != it.cursor
+		it.hasNextPage = txs.HasNextPage && moved
+		if moved {
+			it.cursor = txs.NextCursor
+		}
+		// all txs of this page filtered by client side filters, fetch next page
+		if len(it.txs) > 0 || !it.hasNextPage {
+			return true
+		}
+	}
+}
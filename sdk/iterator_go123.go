@@ -0,0 +1,22 @@
+//go:build go1.23
+
+package sdk
+
+import (
+	"iter"
+
+	"github.com/everVision/everpay-kits/schema"
+)
+
+// All return an iter.Seq of all txs, check it.Err() after the loop
+//
+//	for tx := range it.All() {}
+func (it *TxIterator) All() iter.Seq[schema.TxResponse] {
+	return func(yield func(schema.TxResponse) bool) {
+		for it.Next() {
+			if !yield(it.Tx()) {
+				return
+			}
+		}
+	}
+}
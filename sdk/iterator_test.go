@@ -0,0 +1,90 @@
+package sdk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxIterator(t *testing.T) {
+	srv := newTestPayServer()
+	srv.txResps = genTestTxs(250, "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82")
+	defer srv.Close()
+	cli := NewClient(srv.URL)
+
+	it := cli.NewTxIterator(0, OrderByAsc, schema.TxOpts{})
+	it.SetRateLimit(0)
+	rawIds := make([]int64, 0)
+	for it.Next() {
+		rawIds = append(rawIds, it.Tx().RawId)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 250, len(rawIds))
+	assert.Equal(t, int64(1), rawIds[0])
+	assert.Equal(t, int64(250), rawIds[249])
+
+	it = cli.NewTxIterator(0, OrderByDesc, schema.TxOpts{})
+	it.SetRateLimit(0)
+	it.SetPageLimit(30)
+	rawIds = rawIds[:0]
+	for it.Next() {
+		rawIds = append(rawIds, it.Tx().RawId)
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 250, len(rawIds))
+	assert.Equal(t, int64(250), rawIds[0])
+	assert.Equal(t, int64(1), rawIds[249])
+}
+
+func TestExportAccTxs(t *testing.T) {
+	accid := "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82"
+	srv := newTestPayServer()
+	srv.txResps = genTestTxs(120, accid)
+	defer srv.Close()
+	cli := NewClient(srv.URL)
+
+	buf := &bytes.Buffer{}
+	num, err := cli.ExportAccTxs(accid, ExportFormatJSONL, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 120, num)
+	assert.Equal(t, 120, strings.Count(buf.String(), "\n"))
+
+	buf.Reset()
+	num, err = cli.ExportAccTxs(accid, ExportFormatCSV, buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 120, num)
+	records, err := csv.NewReader(buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, 121, len(records))
+	assert.Equal(t, "120", records[120][0])
+}
+
+func TestTxIterator_ClientFilter(t *testing.T) {
+	srv := newTestPayServer()
+	srv.txResps = genTestTxs(250, "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82")
+	srv.txResps[204].Action = schema.TxActionBurn
+	defer srv.Close()
+	cli := NewClient(srv.URL)
+
+	// most of pages are filtered to empty
+	it := cli.NewTxIterator(0, OrderByAsc, schema.TxOpts{
+		From:      "0x3d7e9dfbc58952fdacee2a5c69367c8478474d82",
+		Actions:   []string{schema.TxActionTransfer, schema.TxActionMint},
+		MinAmount: big.NewInt(200),
+		MaxAmount: big.NewInt(210),
+	})
+	it.SetRateLimit(0)
+	it.SetPageLimit(30)
+	num := 0
+	for it.Next() {
+		assert.NotEqual(t, schema.TxActionBurn, it.Tx().Action)
+		num++
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 10, num)
+}
@@ -0,0 +1,89 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/tidwall/sjson"
+)
+
+// sendListChunked validate ids by utils.IDCheck, skip the ids in current list for add action
+// or not in current list for remove action, then send the rest by chunks.
+// chunks are sent in order and stop at the first failed chunk
+func (s *SDK) sendListChunked(tokenTag, action, dataKey string, ids []string, currentList func(tokenTag string) ([]string, error)) ([]schema.ListChunkResult, error) {
+	tokenInfo, ok := s.tokens[tokenTag]
+	if !ok {
+		return nil, schema.ERR_TOKEN_NOT_EXIST
+	}
+	current, err := currentList(tokenTag)
+	if err != nil {
+		return nil, err
+	}
+	list, _, err := diffList(ids, current)
+	if err != nil {
+		return nil, err
+	}
+	if action == schema.TxActionRemoveWhiteList || action == schema.TxActionRemoveBlackList {
+		all, _, _ := diffList(ids, nil)
+		list = subtractList(all, list)
+	}
+
+	chunks := chunkList(list, len(fmt.Sprintf(`{"%s":[]}`, dataKey)), schema.MaxTxDataLength)
+	results := make([]schema.ListChunkResult, 0, len(chunks))
+	for _, chunk := range chunks {
+		res := schema.ListChunkResult{List: chunk}
+		data, err := sjson.Set("", dataKey, chunk)
+		if err == nil {
+			res.Tx, err = s.sendTx(tokenInfo, action, "0", s.AccId, big.NewInt(0), data)
+		}
+		res.Err = err
+		results = append(results, res)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// subtractList return the ids in a but not in b
+func subtractList(a, b []string) []string {
+	exclude := make(map[string]bool, len(b))
+	for _, id := range b {
+		exclude[id] = true
+	}
+	res := make([]string, 0, len(a))
+	for _, id := range a {
+		if !exclude[id] {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// chunkList split ids into chunks, the json array of each chunk plus overhead not more than maxLen
+func chunkList(ids []string, overhead, maxLen int) [][]string {
+	chunks := make([][]string, 0)
+	chunk := make([]string, 0)
+	size := overhead
+	for _, id := range ids {
+		by, _ := json.Marshal(id)
+		itemLen := len(by)
+		if len(chunk) > 0 {
+			itemLen++ // comma
+		}
+		if len(chunk) > 0 && size+itemLen > maxLen {
+			chunks = append(chunks, chunk)
+			chunk = make([]string, 0)
+			size = overhead
+			itemLen = len(by)
+		}
+		chunk = append(chunk, id)
+		size += itemLen
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
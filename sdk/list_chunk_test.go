@@ -0,0 +1,104 @@
+package sdk
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+func TestChunkList(t *testing.T) {
+	ids := []string{"aa", "bb", "cc"}
+	// `{"k":[]}` 8, `"aa"` 4, `,"bb"` 5
+	assert.Equal(t, [][]string{{"aa", "bb"}, {"cc"}}, chunkList(ids, 8, 17))
+	assert.Equal(t, [][]string{{"aa"}, {"bb"}, {"cc"}}, chunkList(ids, 8, 16))
+	assert.Equal(t, [][]string{{"aa", "bb", "cc"}}, chunkList(ids, 8, 100))
+	assert.Equal(t, 0, len(chunkList(nil, 8, 100)))
+}
+
+func TestAddWhiteListTxs(t *testing.T) {
+	ids := make([]string, 0)
+	for i := 0; i < 1500; i++ {
+		ids = append(ids, common.BigToAddress(big.NewInt(int64(i+1))).String())
+	}
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.extra = schema.Tns102Extra{Owner: testSignerAddr}
+	srv.whiteList = []string{strings.ToLower(ids[0]), ids[1]}
+	s := srv.newSDK(t)
+
+	_, err := s.AddWhiteListTxs(testTokenTag, []string{ids[2], "invalid"})
+	assert.ErrorIs(t, err, schema.ERR_INVALID_ID)
+	assert.Equal(t, 0, len(srv.submitted()))
+
+	results, err := s.AddWhiteListTxs(testTokenTag, append(ids, ids[5], strings.ToLower(ids[6])))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(results))
+	txs := srv.submitted()
+	added := 0
+	for i, res := range results {
+		assert.NoError(t, res.Err)
+		assert.True(t, len(txs[i].Data) <= schema.MaxTxDataLength, fmt.Sprintf("chunk %d data len %d", i, len(txs[i].Data)))
+		assert.Equal(t, len(res.List), len(gjson.Get(txs[i].Data, "whiteList").Array()))
+		added += len(res.List)
+	}
+	assert.Equal(t, 1498, added)
+	srv.update(func(p *testPayServer) { assert.Equal(t, 1500, len(p.whiteList)) })
+
+	// all exist
+	results, err = s.AddWhiteListTxs(testTokenTag, ids[:10])
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(results))
+}
+
+func TestRemoveBlackListTxs(t *testing.T) {
+	ids := make([]string, 0)
+	for i := 0; i < 1500; i++ {
+		ids = append(ids, common.BigToAddress(big.NewInt(int64(i+1))).String())
+	}
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.extra = schema.Tns102Extra{Owner: testSignerAddr}
+	srv.blackList = append([]string{}, ids...)
+	srv.blackList[0] = strings.ToLower(ids[0])
+	s := srv.newSDK(t)
+
+	// ids not in black list are skipped
+	remove := append(append([]string{}, ids[:1400]...), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", ids[3])
+	results, err := s.RemoveBlackListTxs(testTokenTag, remove)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(results))
+	txs := srv.submitted()
+	removed := 0
+	for i, res := range results {
+		assert.NoError(t, res.Err)
+		assert.Equal(t, schema.TxActionRemoveBlackList, txs[i].Action)
+		assert.True(t, len(txs[i].Data) <= schema.MaxTxDataLength, fmt.Sprintf("chunk %d data len %d", i, len(txs[i].Data)))
+		removed += len(res.List)
+	}
+	assert.Equal(t, 1400, removed)
+	srv.update(func(p *testPayServer) { assert.Equal(t, ids[1400:], p.blackList) })
+
+	results, err = s.RemoveBlackListTxs(testTokenTag, ids[:10])
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(results))
+}
+
+func TestAddBlackListTx(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.extra = schema.Tns102Extra{Owner: testSignerAddr}
+	srv.blackList = []string{"0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"}
+	s := srv.newSDK(t)
+
+	// single tx, ids are sent as is
+	tx, err := s.AddBlackListTx(testTokenTag, []string{"0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"blackList":["0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"]}`, tx.Data)
+	assert.Equal(t, 1, len(srv.submitted()))
+}
@@ -0,0 +1,147 @@
+package sdk
+
+import (
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/tidwall/sjson"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/context"
+	"gopkg.in/h2non/gentleman.v2/plugin"
+	"gopkg.in/h2non/gentleman.v2/plugins/body"
+	"gopkg.in/h2non/gentleman.v2/plugins/timeout"
+	"gopkg.in/h2non/gentleman.v2/plugins/transport"
+)
+
+// PayRequestURI decode everpay payment URI and pay it
+func (s *SDK) PayRequestURI(uri string) (*schema.Transaction, error) {
+	req, err := utils.DecodePaymentRequest(uri)
+	if err != nil {
+		return nil, err
+	}
+	return s.PayRequest(req)
+}
+
+// PaymentNotifyOpts payer opts in to POST schema.PaymentNotify to the callback of payment request
+type PaymentNotifyOpts struct {
+	AllowedHosts []string          // required, hostname of callback must be one of AllowedHosts
+	Timeout      time.Duration     // default 5s
+	Transport    http.RoundTripper // option, default http.DefaultTransport
+}
+
+// PayRequest transfer to the recipient of payment request with memo in tx data,
+// req.Callback is not notified, use PayRequestAndNotify to notify it
+func (s *SDK) PayRequest(req schema.PaymentRequest) (*schema.Transaction, error) {
+	if err := utils.CheckPaymentRequest(req); err != nil {
+		return nil, err
+	}
+	if req.Expiration > 0 && time.Now().Unix() > req.Expiration {
+		return nil, schema.ERR_PAYMENT_REQUEST_EXPIRED
+	}
+	tokenInfo, ok := s.tokens[req.TokenTag]
+	if !ok {
+		return nil, schema.ERR_TOKEN_NOT_EXIST
+	}
+	amount, err := utils.ParseAmount(req.Amount, tokenInfo.Decimals)
+	if err != nil {
+		return nil, err
+	}
+
+	data := ""
+	if req.Memo != "" {
+		if data, err = sjson.Set("", "memo", req.Memo); err != nil {
+			return nil, err
+		}
+	}
+	return s.Transfer(req.TokenTag, amount, req.Recipient, data)
+}
+
+// PayRequestAndNotify pay the request and notify the everHash to req.Callback, notify error is only logged.
+// return ERR_CALLBACK_NOT_ALLOWED without paying if the callback host is not allowed
+func (s *SDK) PayRequestAndNotify(req schema.PaymentRequest, opts PaymentNotifyOpts) (*schema.Transaction, error) {
+	if req.Callback != "" {
+		if err := checkCallback(req.Callback, opts.AllowedHosts); err != nil {
+			return nil, err
+		}
+	}
+	everTx, err := s.PayRequest(req)
+	if err != nil || req.Callback == "" {
+		return everTx, err
+	}
+
+	notify := schema.PaymentNotify{
+		EverHash: everTx.HexHash(),
+		From:     everTx.From,
+		To:       everTx.To,
+		TokenTag: req.TokenTag,
+		Amount:   everTx.Amount,
+		Memo:     req.Memo,
+	}
+	if err := notifyPayment(req.Callback, notify, opts); err != nil {
+		log.Error("notify payment callback failed", "callback", req.Callback, "everHash", notify.EverHash, "err", err)
+	}
+	return everTx, nil
+}
+
+// NewPaymentRequestURI merchant create payment request URI, amount is integer amount of token
+func (s *SDK) NewPaymentRequestURI(tokenTag string, amount *big.Int, memo string, expiration int64, callback string) (string, error) {
+	tokenInfo, ok := s.tokens[tokenTag]
+	if !ok {
+		return "", schema.ERR_TOKEN_NOT_EXIST
+	}
+	return utils.EncodePaymentRequest(schema.PaymentRequest{
+		Recipient:  s.AccId,
+		TokenTag:   tokenTag,
+		Amount:     utils.FormatAmount(amount, tokenInfo.Decimals),
+		Memo:       memo,
+		Expiration: expiration,
+		Callback:   callback,
+	})
+}
+
+// checkCallback callback must be https url of allowed hosts
+func checkCallback(callback string, allowedHosts []string) error {
+	u, err := url.Parse(callback)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return schema.ERR_INVALID_PAYMENT_REQUEST
+	}
+	if !schema.ContainsStr(allowedHosts, u.Hostname(), true) {
+		return schema.ERR_CALLBACK_NOT_ALLOWED
+	}
+	return nil
+}
+
+func notifyPayment(callback string, notify schema.PaymentNotify, opts PaymentNotifyOpts) error {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	cli := gentleman.New()
+	if opts.Transport != nil {
+		cli.Use(transport.Set(opts.Transport))
+	}
+	req := cli.URL(callback).Request()
+	req.Method("POST")
+	req.Use(timeout.Request(opts.Timeout))
+	// redirect may leave the allowed hosts
+	req.Use(plugin.NewRequestPlugin(func(ctx *context.Context, h context.Handler) {
+		ctx.Client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		h.Next(ctx)
+	}))
+	req.Use(body.JSON(notify))
+	res, err := req.Send()
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	// redirect response is not Ok too
+	if res.StatusCode/100 != 2 {
+		return decodeRespErr(res.Bytes())
+	}
+	return nil
+}
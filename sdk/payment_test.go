@@ -0,0 +1,127 @@
+package sdk
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentRequestURI(t *testing.T) {
+	req := schema.PaymentRequest{
+		Recipient:  "merchant+shop@everpay.io",
+		TokenTag:   "arweave,ethereum-ar-AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA,0xcc9141efa8c20c7df0778748255b1487957811be",
+		Amount:     "1.5",
+		Memo:       "order #1024",
+		Expiration: 1700000000,
+		Callback:   "https://shop.example.com/notify?id=1024",
+	}
+	uri, err := utils.EncodePaymentRequest(req)
+	assert.NoError(t, err)
+	decoded, err := utils.DecodePaymentRequest(uri)
+	assert.NoError(t, err)
+	assert.Equal(t, req, decoded)
+
+	_, err = utils.DecodePaymentRequest("everpay:0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223?tag=ethereum-eth-0x0000000000000000000000000000000000000000&amount=-1")
+	assert.Equal(t, schema.ERR_INVALID_AMOUNT, err)
+	_, err = utils.DecodePaymentRequest("everpay:invalid?tag=ethereum-eth-0x0000000000000000000000000000000000000000&amount=1")
+	assert.Equal(t, schema.ERR_INVALID_ID, err)
+}
+
+func TestParseAmount(t *testing.T) {
+	amount, err := utils.ParseAmount("1.5", 6)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1500000), amount)
+	assert.Equal(t, "1.5", utils.FormatAmount(amount, 6))
+	assert.Equal(t, "2", utils.FormatAmount(big.NewInt(2000000), 6))
+
+	_, err = utils.ParseAmount("1.0000001", 6)
+	assert.Equal(t, schema.ERR_INVALID_AMOUNT, err)
+	_, err = utils.ParseAmount("1e6", 6)
+	assert.Equal(t, schema.ERR_INVALID_AMOUNT, err)
+}
+
+func TestSDK_PayRequestAndNotify(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	s := srv.newSDK(t)
+
+	notified := make(chan schema.PaymentNotify, 1)
+	shop := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notify := schema.PaymentNotify{}
+		json.NewDecoder(r.Body).Decode(&notify)
+		notified <- notify
+	}))
+	defer shop.Close()
+	opts := PaymentNotifyOpts{AllowedHosts: []string{"127.0.0.1"}, Transport: shop.Client().Transport}
+
+	req := schema.PaymentRequest{
+		Recipient: "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223",
+		TokenTag:  testTokenTag,
+		Amount:    "10",
+		Memo:      "order #1024",
+		Callback:  shop.URL + "/notify?id=1024",
+	}
+	// not opted in
+	everTx, err := s.PayRequest(req)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"memo":"order #1024"}`, everTx.Data)
+	select {
+	case <-notified:
+		t.Fatal("callback notified without opt in")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	everTx, err = s.PayRequestAndNotify(req, opts)
+	assert.NoError(t, err)
+	select {
+	case notify := <-notified:
+		assert.Equal(t, schema.PaymentNotify{
+			EverHash: everTx.HexHash(), From: testSignerAddr, To: req.Recipient, TokenTag: testTokenTag, Amount: "10", Memo: req.Memo,
+		}, notify)
+	case <-time.After(5 * time.Second):
+		t.Fatal("callback not notified")
+	}
+	assert.Equal(t, 2, len(srv.submitted()))
+
+	// callback checked before paying
+	_, err = s.PayRequestAndNotify(req, PaymentNotifyOpts{AllowedHosts: []string{"shop.example.com"}})
+	assert.Equal(t, schema.ERR_CALLBACK_NOT_ALLOWED, err)
+	_, err = s.PayRequestAndNotify(req, PaymentNotifyOpts{})
+	assert.Equal(t, schema.ERR_CALLBACK_NOT_ALLOWED, err)
+	req.Callback = "http://127.0.0.1/notify"
+	_, err = s.PayRequestAndNotify(req, opts)
+	assert.Equal(t, schema.ERR_INVALID_PAYMENT_REQUEST, err)
+	_, err = s.PayRequest(req)
+	assert.Equal(t, schema.ERR_INVALID_PAYMENT_REQUEST, err)
+	assert.Equal(t, 2, len(srv.submitted()))
+}
+
+func TestNotifyPayment(t *testing.T) {
+	slow := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer slow.Close()
+	err := notifyPayment(slow.URL, schema.PaymentNotify{}, PaymentNotifyOpts{Timeout: 50 * time.Millisecond, Transport: slow.Client().Transport})
+	assert.Error(t, err)
+
+	// redirect is not followed
+	redirected := false
+	other := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected = true
+	}))
+	defer other.Close()
+	redirect := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, other.URL, http.StatusTemporaryRedirect)
+	}))
+	defer redirect.Close()
+	err = notifyPayment(redirect.URL, schema.PaymentNotify{}, PaymentNotifyOpts{Transport: redirect.Client().Transport})
+	assert.Error(t, err)
+	assert.False(t, redirected)
+}
@@ -0,0 +1,437 @@
+package sdk
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+)
+
+const dailyWindow = 24 * time.Hour
+
+// policyActions the actions can be forbidden by policy
+var policyActions = []string{
+	schema.TxActionTransfer, schema.TxActionMint, schema.TxActionBurn, schema.TxActionBundle,
+	schema.TxActionTransferOwner, schema.TxActionSet, schema.TxActionRegister,
+	schema.TxActionAddWhiteList, schema.TxActionRemoveWhiteList, schema.TxActionPauseWhiteList,
+	schema.TxActionAddBlackList, schema.TxActionRemoveBlackList, schema.TxActionPauseBlackList, schema.TxActionPause,
+}
+
+type tokenRule struct {
+	maxAmount      *big.Int
+	dailyLimit     *big.Int
+	velocityCount  int
+	velocityWindow time.Duration
+}
+
+type timeWindow struct {
+	start, end int // minutes of day
+	weekdays   map[time.Weekday]bool
+}
+
+type spendRecord struct {
+	time   time.Time
+	amount *big.Int
+	count  int // txs or bundle items counted by velocity limit
+}
+
+// PolicyEngine evaluate txs with spending policy before signing
+type PolicyEngine struct {
+	forbiddenActions map[string]bool // key: lower case action
+	recipients       []string
+	tokens           map[string]tokenRule // key: tag normalized by NormalizeTag
+	windows          []timeWindow
+	loc              *time.Location
+
+	lock    sync.Mutex
+	history map[string][]spendRecord // normalized tokenTag -> spends of allowed txs
+
+	now func() time.Time
+}
+
+// NewPolicyEngine return error if policy has unknown forbidden actions or unparsable token tags
+func NewPolicyEngine(policy schema.Policy) (*PolicyEngine, error) {
+	p := &PolicyEngine{
+		forbiddenActions: make(map[string]bool),
+		recipients:       make([]string, 0, len(policy.AllowedRecipients)),
+		tokens:           make(map[string]tokenRule),
+		loc:              time.UTC,
+		history:          make(map[string][]spendRecord),
+		now:              time.Now,
+	}
+	for _, to := range policy.AllowedRecipients {
+		p.recipients = append(p.recipients, utils.FormatAccId(to))
+	}
+	for _, action := range policy.ForbiddenActions {
+		if !schema.ContainsStr(policyActions, action, true) {
+			return nil, fmt.Errorf("invalid forbidden action: %s", action)
+		}
+		p.forbiddenActions[strings.ToLower(action)] = true
+	}
+	if policy.Timezone != "" {
+		loc, err := time.LoadLocation(policy.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		p.loc = loc
+	}
+
+	for tag, tp := range policy.Tokens {
+		normalized, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, fmt.Errorf("token %s: %w", tag, err)
+		}
+		if _, ok := p.tokens[normalized]; ok {
+			return nil, fmt.Errorf("token %s: duplicated", tag)
+		}
+		rule := tokenRule{velocityCount: tp.VelocityCount}
+		if rule.maxAmount, err = parsePolicyAmount(tp.MaxAmount); err != nil {
+			return nil, fmt.Errorf("token %s maxAmount: %w", tag, err)
+		}
+		if rule.dailyLimit, err = parsePolicyAmount(tp.DailyLimit); err != nil {
+			return nil, fmt.Errorf("token %s dailyLimit: %w", tag, err)
+		}
+		if tp.VelocityCount > 0 {
+			if rule.velocityWindow, err = time.ParseDuration(tp.VelocityWindow); err != nil {
+				return nil, fmt.Errorf("token %s velocityWindow: %w", tag, err)
+			}
+		}
+		p.tokens[normalized] = rule
+	}
+
+	for _, tw := range policy.TimeWindows {
+		w := timeWindow{weekdays: make(map[time.Weekday]bool)}
+		var err error
+		if w.start, err = parseClock(tw.Start); err != nil {
+			return nil, err
+		}
+		if w.end, err = parseClock(tw.End); err != nil {
+			return nil, err
+		}
+		for _, day := range tw.Weekdays {
+			wd, ok := weekdays[strings.ToLower(day)]
+			if !ok {
+				return nil, fmt.Errorf("invalid weekday: %s", day)
+			}
+			w.weekdays[wd] = true
+		}
+		p.windows = append(p.windows, w)
+	}
+	return p, nil
+}
+
+// LoadPolicy load policy from yaml (.yaml, .yml) or json file
+func LoadPolicy(path string) (*PolicyEngine, error) {
+	policy := schema.Policy{}
+	if err := loadConfigFile(path, &policy); err != nil {
+		return nil, err
+	}
+	return NewPolicyEngine(policy)
+}
+
+// NormalizeTag return the tag generated by schema.GenTag of the parsed tag,
+// chainType and symbol are lower case, EVM token id is lower case
+func NormalizeTag(tag string) (string, error) {
+	chainType, symbol, id, err := schema.ParseTag(tag)
+	if err != nil {
+		return "", err
+	}
+	chainType = strings.ToLower(chainType)
+	if _, err = schema.FormatTokenID(chainType, id); err != nil {
+		return "", err
+	}
+	return schema.GenTag(chainType, symbol, id), nil
+}
+
+// Tokens return the normalized tags of policy tokens
+func (p *PolicyEngine) Tokens() []string {
+	tags := make([]string, 0, len(p.tokens))
+	for tag := range p.tokens {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// HasToken check tokenTag is one of the policy tokens
+func (p *PolicyEngine) HasToken(tokenTag string) bool {
+	_, _, ok := p.rule(tokenTag)
+	return ok
+}
+
+// rule return the normalized tokenTag and its rule
+func (p *PolicyEngine) rule(tokenTag string) (string, tokenRule, bool) {
+	tag, err := NormalizeTag(tokenTag)
+	if err != nil {
+		return tokenTag, tokenRule{}, false
+	}
+	rule, ok := p.tokens[tag]
+	return tag, rule, ok
+}
+
+// Check return schema.PolicyViolation if tx not allowed, amount and fee are integer strings
+func (p *PolicyEngine) Check(tokenTag, action, to, amount, fee string) error {
+	return p.check(tokenTag, action, []string{to}, 1, amount, fee)
+}
+
+// CheckBundle check the bundle items of tokenTag spent by signer as one spend,
+// the amounts are summed and every item is counted by velocity limit
+func (p *PolicyEngine) CheckBundle(tokenTag string, items []schema.BundleItem) error {
+	tos, amounts := bundleSpend(items)
+	return p.check(tokenTag, schema.TxActionBundle, tos, len(items), amounts...)
+}
+
+func (p *PolicyEngine) check(tokenTag, action string, tos []string, count int, amounts ...string) error {
+	violation := func(rule, msg string) error {
+		return schema.PolicyViolation{Rule: rule, Action: action, TokenTag: tokenTag, Msg: msg}
+	}
+
+	if p.forbiddenActions[strings.ToLower(action)] {
+		return violation(schema.PolicyRuleForbiddenAction, "action is forbidden")
+	}
+	now := p.now()
+	if !p.inTimeWindows(now) {
+		return violation(schema.PolicyRuleTimeWindow, fmt.Sprintf("not in time windows: %s", now.In(p.loc).Format("Mon 15:04")))
+	}
+	if isSpendAction(action) && len(p.recipients) > 0 {
+		for _, to := range tos {
+			if !schema.ContainsStr(p.recipients, utils.FormatAccId(to), false) {
+				return violation(schema.PolicyRuleRecipient, fmt.Sprintf("recipient not allowed: %s", to))
+			}
+		}
+	}
+
+	tag, rule, ok := p.rule(tokenTag)
+	if !ok {
+		return nil
+	}
+	total, err := txTotal(amounts...)
+	if err != nil {
+		return err
+	}
+	if rule.maxAmount != nil && total.Cmp(rule.maxAmount) > 0 {
+		return violation(schema.PolicyRuleMaxAmount, fmt.Sprintf("amount %s over max amount %s", total, rule.maxAmount))
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	history := p.prune(tag, now)
+	if rule.dailyLimit != nil {
+		spent := new(big.Int).Set(total)
+		for _, r := range history {
+			if now.Sub(r.time) < dailyWindow {
+				spent.Add(spent, r.amount)
+			}
+		}
+		if spent.Cmp(rule.dailyLimit) > 0 {
+			return violation(schema.PolicyRuleDailyLimit, fmt.Sprintf("daily spent %s over limit %s", spent, rule.dailyLimit))
+		}
+	}
+	if rule.velocityCount > 0 {
+		for _, r := range history {
+			if now.Sub(r.time) < rule.velocityWindow {
+				count += r.count
+			}
+		}
+		if count > rule.velocityCount {
+			return violation(schema.PolicyRuleVelocity, fmt.Sprintf("over %d txs in %s", rule.velocityCount, rule.velocityWindow))
+		}
+	}
+	return nil
+}
+
+// Evaluate Check tx and return the decision as audit record
+func (p *PolicyEngine) Evaluate(signer, tokenTag, action, to, amount, fee, nonce string) (schema.AuditRecord, error) {
+	err := p.Check(tokenTag, action, to, amount, fee)
+	record := schema.AuditRecord{
+		Time:     time.Now().UnixMilli(),
+		Event:    schema.AuditEventPolicy,
+		Signer:   signer,
+		TokenTag: tokenTag,
+		Action:   action,
+		To:       to,
+		Amount:   amount,
+		Fee:      fee,
+		Nonce:    nonce,
+	}
+	return decide(record, err), err
+}
+
+// EvaluateBundle CheckBundle items of tokenTag, the recipients are joined by "," and amount is the sum in audit record
+func (p *PolicyEngine) EvaluateBundle(signer, tokenTag, nonce string, items []schema.BundleItem) (schema.AuditRecord, error) {
+	err := p.CheckBundle(tokenTag, items)
+	tos, amounts := bundleSpend(items)
+	record := schema.AuditRecord{
+		Time:     time.Now().UnixMilli(),
+		Event:    schema.AuditEventPolicy,
+		Signer:   signer,
+		TokenTag: tokenTag,
+		Action:   schema.TxActionBundle,
+		To:       strings.Join(tos, ","),
+		Nonce:    nonce,
+	}
+	if total, totalErr := txTotal(amounts...); totalErr == nil {
+		record.Amount = total.String()
+	}
+	return decide(record, err), err
+}
+
+func decide(record schema.AuditRecord, err error) schema.AuditRecord {
+	record.Decision = schema.PolicyDecisionAllow
+	if err != nil {
+		record.Decision = schema.PolicyDecisionDeny
+		record.Reason = err.Error()
+		if v, ok := err.(schema.PolicyViolation); ok {
+			record.Rule = v.Rule
+			record.Reason = v.Msg
+		}
+		log.Warn("tx denied by policy", "tokenTag", record.TokenTag, "action", record.Action, "to", record.To, "amount", record.Amount, "err", err)
+	}
+	return record
+}
+
+// Record record the spend of signed tx for daily and velocity limits
+func (p *PolicyEngine) Record(tokenTag, amount, fee string) {
+	p.record(tokenTag, 1, amount, fee)
+}
+
+// RecordBundle record the bundle items of tokenTag spent by signer
+func (p *PolicyEngine) RecordBundle(tokenTag string, items []schema.BundleItem) {
+	_, amounts := bundleSpend(items)
+	p.record(tokenTag, len(items), amounts...)
+}
+
+func (p *PolicyEngine) record(tokenTag string, count int, amounts ...string) {
+	tag, _, ok := p.rule(tokenTag)
+	if !ok {
+		return
+	}
+	total, err := txTotal(amounts...)
+	if err != nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.history[tag] = append(p.history[tag], spendRecord{time: p.now(), amount: total, count: count})
+}
+
+// BundleSpends return the bundle items spent by from grouped by token tag normalized by NormalizeTag,
+// tags are in the order of items, unparsable tag is kept as it is
+func BundleSpends(bundle schema.Bundle, from string) (tags []string, items map[string][]schema.BundleItem) {
+	from = utils.FormatAccId(from)
+	items = make(map[string][]schema.BundleItem)
+	for _, item := range bundle.Items {
+		if utils.FormatAccId(item.From) != from {
+			continue
+		}
+		tag, err := NormalizeTag(item.Tag)
+		if err != nil {
+			tag = item.Tag
+		}
+		if _, ok := items[tag]; !ok {
+			tags = append(tags, tag)
+		}
+		items[tag] = append(items[tag], item)
+	}
+	return tags, items
+}
+
+// prune remove the history out of daily and velocity windows, tokenTag is normalized
+func (p *PolicyEngine) prune(tokenTag string, now time.Time) []spendRecord {
+	keep := dailyWindow
+	if w := p.tokens[tokenTag].velocityWindow; w > keep {
+		keep = w
+	}
+	history := p.history[tokenTag][:0]
+	for _, r := range p.history[tokenTag] {
+		if now.Sub(r.time) < keep {
+			history = append(history, r)
+		}
+	}
+	p.history[tokenTag] = history
+	return history
+}
+
+func (p *PolicyEngine) inTimeWindows(t time.Time) bool {
+	if len(p.windows) == 0 {
+		return true
+	}
+	t = t.In(p.loc)
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range p.windows {
+		day := t.Weekday()
+		var in bool
+		if w.start <= w.end {
+			in = minute >= w.start && minute < w.end
+		} else { // overnight, the part after midnight belongs to the window of yesterday
+			if minute < w.end {
+				day = (day + 6) % 7
+			}
+			in = minute >= w.start || minute < w.end
+		}
+		if in && (len(w.weekdays) == 0 || w.weekdays[day]) {
+			return true
+		}
+	}
+	return false
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func parsePolicyAmount(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	amount, ok := new(big.Int).SetString(s, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, schema.ERR_INVALID_AMOUNT
+	}
+	return amount, nil
+}
+
+func txTotal(amounts ...string) (*big.Int, error) {
+	total := big.NewInt(0)
+	for _, s := range amounts {
+		if s == "" {
+			continue
+		}
+		v, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, schema.ERR_INVALID_AMOUNT
+		}
+		total.Add(total, v)
+	}
+	return total, nil
+}
+
+func isSpendAction(action string) bool {
+	switch strings.ToLower(action) {
+	case schema.TxActionTransfer, schema.TxActionBurn, schema.TxActionBundle:
+		return true
+	}
+	return false
+}
+
+func bundleSpend(items []schema.BundleItem) (tos, amounts []string) {
+	for _, item := range items {
+		tos = append(tos, item.To)
+		amounts = append(amounts, item.Amount)
+	}
+	return tos, amounts
+}
@@ -0,0 +1,259 @@
+package sdk
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertViolation(t *testing.T, err error, rule string) {
+	v := schema.PolicyViolation{}
+	if assert.True(t, errors.As(err, &v), "err: %v", err) {
+		assert.Equal(t, rule, v.Rule)
+	}
+}
+
+func TestPolicyEngine_Check(t *testing.T) {
+	p, err := NewPolicyEngine(schema.Policy{
+		ForbiddenActions:  []string{schema.TxActionTransferOwner},
+		AllowedRecipients: []string{"0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"},
+		Tokens: map[string]schema.TokenPolicy{
+			testTokenTag: {MaxAmount: "100", DailyLimit: "150", VelocityCount: 3, VelocityWindow: "1h"},
+		},
+	})
+	assert.NoError(t, err)
+	now := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	to := "0x4002ed1a1410af1b4930cf6c479ae373debd6223"
+
+	assertViolation(t, p.Check(testTokenTag, schema.TxActionTransferOwner, to, "0", "0"), schema.PolicyRuleForbiddenAction)
+	assertViolation(t, p.Check(testTokenTag, schema.TxActionTransfer, "0x61EbF673c200646236B2c53465bcA0699455d5FA", "1", "0"), schema.PolicyRuleRecipient)
+	assertViolation(t, p.Check(testTokenTag, schema.TxActionTransfer, to, "100", "1"), schema.PolicyRuleMaxAmount)
+
+	assert.NoError(t, p.Check(testTokenTag, schema.TxActionTransfer, to, "99", "1"))
+	p.Record(testTokenTag, "99", "1")
+	assertViolation(t, p.Check(testTokenTag, schema.TxActionTransfer, to, "50", "1"), schema.PolicyRuleDailyLimit)
+
+	assert.NoError(t, p.Check(testTokenTag, schema.TxActionTransfer, to, "10", "0"))
+	p.Record(testTokenTag, "10", "0")
+	p.Record(testTokenTag, "10", "0")
+	assertViolation(t, p.Check(testTokenTag, schema.TxActionTransfer, to, "1", "0"), schema.PolicyRuleVelocity)
+
+	// after 1h velocity reset, daily limit still 120 spent
+	now = now.Add(time.Hour)
+	assert.NoError(t, p.Check(testTokenTag, schema.TxActionTransfer, to, "30", "0"))
+	assertViolation(t, p.Check(testTokenTag, schema.TxActionTransfer, to, "31", "0"), schema.PolicyRuleDailyLimit)
+	// after 24h daily reset
+	now = now.Add(24 * time.Hour)
+	assert.NoError(t, p.Check(testTokenTag, schema.TxActionTransfer, to, "100", "0"))
+
+	// token not in policy
+	assert.NoError(t, p.Check("arweave,ethereum-ar-AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA,0x4fadc7a98f2dc96510e42dd1a74141eeae0c1543", schema.TxActionTransfer, to, "1000", "0"))
+}
+
+func TestPolicyEngine_Normalize(t *testing.T) {
+	upperTag := "Ethereum-USDT-0xDAC17F958D2EE523A2206206994597C13D831EC7"
+	p, err := NewPolicyEngine(schema.Policy{
+		ForbiddenActions: []string{"TransferOwner"},
+		Tokens:           map[string]schema.TokenPolicy{upperTag: {DailyLimit: "100"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{testTokenTag}, p.Tokens())
+	assert.True(t, p.HasToken(testTokenTag))
+	assert.True(t, p.HasToken(upperTag))
+	assert.False(t, p.HasToken("ethereum-usdt"))
+
+	assertViolation(t, p.Check(testTokenTag, schema.TxActionTransferOwner, "", "0", "0"), schema.PolicyRuleForbiddenAction)
+	assertViolation(t, p.Check(testTokenTag, "TRANSFEROWNER", "", "0", "0"), schema.PolicyRuleForbiddenAction)
+	// spends of tags in any case share the limit
+	assert.NoError(t, p.Check(upperTag, schema.TxActionTransfer, "", "60", "0"))
+	p.Record(upperTag, "60", "0")
+	assertViolation(t, p.Check(testTokenTag, schema.TxActionTransfer, "", "60", "0"), schema.PolicyRuleDailyLimit)
+	assertViolation(t, p.Check("ethereum-usdt-0xdac17f958d2ee523a2206206994597c13d831ec7", schema.TxActionTransfer, "", "41", "0"), schema.PolicyRuleDailyLimit)
+
+	_, err = NewPolicyEngine(schema.Policy{Tokens: map[string]schema.TokenPolicy{"ethereum-usdt": {MaxAmount: "1"}}})
+	assert.ErrorIs(t, err, schema.ERR_INVALID_TAG)
+	_, err = NewPolicyEngine(schema.Policy{Tokens: map[string]schema.TokenPolicy{upperTag: {MaxAmount: "1"}, testTokenTag: {MaxAmount: "2"}}})
+	assert.Error(t, err)
+	_, err = NewPolicyEngine(schema.Policy{ForbiddenActions: []string{"transfer_owner"}})
+	assert.Error(t, err)
+
+	// recipients of spend action in other case are checked
+	p, err = NewPolicyEngine(schema.Policy{AllowedRecipients: []string{"0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"}})
+	assert.NoError(t, err)
+	assertViolation(t, p.Check(testTokenTag, "Transfer", "0x61EbF673c200646236B2c53465bcA0699455d5FA", "1", "0"), schema.PolicyRuleRecipient)
+
+	// bundle items of the same token in other case are one spend
+	tags, spends := BundleSpends(schema.Bundle{Items: []schema.BundleItem{
+		{Tag: upperTag, From: testSignerAddr, Amount: "1"},
+		{Tag: testTokenTag, From: testSignerAddr, Amount: "2"},
+	}}, testSignerAddr)
+	assert.Equal(t, []string{testTokenTag}, tags)
+	assert.Equal(t, 2, len(spends[testTokenTag]))
+}
+
+func TestPolicyEngine_CheckBundle(t *testing.T) {
+	to := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	p, err := NewPolicyEngine(schema.Policy{
+		AllowedRecipients: []string{"0x4002ed1a1410af1b4930cf6c479ae373debd6223"},
+		Tokens: map[string]schema.TokenPolicy{
+			testTokenTag: {DailyLimit: "100", VelocityCount: 3, VelocityWindow: "1h"},
+		},
+	})
+	assert.NoError(t, err)
+
+	// items of the same token are summed
+	items := []schema.BundleItem{
+		{Tag: testTokenTag, From: testSignerAddr, To: to, Amount: "60"},
+		{Tag: testTokenTag, From: testSignerAddr, To: to, Amount: "60"},
+	}
+	assertViolation(t, p.CheckBundle(testTokenTag, items), schema.PolicyRuleDailyLimit)
+	assert.NoError(t, p.CheckBundle(testTokenTag, items[:1]))
+
+	items = append(items[:1], schema.BundleItem{Tag: testTokenTag, From: testSignerAddr, To: "0x61EbF673c200646236B2c53465bcA0699455d5FA", Amount: "1"})
+	assertViolation(t, p.CheckBundle(testTokenTag, items), schema.PolicyRuleRecipient)
+
+	// every item is counted by velocity limit
+	items = []schema.BundleItem{
+		{Tag: testTokenTag, From: testSignerAddr, To: to, Amount: "1"},
+		{Tag: testTokenTag, From: testSignerAddr, To: to, Amount: "1"},
+	}
+	assert.NoError(t, p.CheckBundle(testTokenTag, items))
+	p.RecordBundle(testTokenTag, items)
+	assert.NoError(t, p.Check(testTokenTag, schema.TxActionTransfer, to, "1", "0"))
+	assertViolation(t, p.CheckBundle(testTokenTag, items), schema.PolicyRuleVelocity)
+}
+
+func TestBundleSpends(t *testing.T) {
+	other := "arweave,ethereum-ar-AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA,0x4fadc7a98f2dc96510e42dd1a74141eeae0c1543"
+	bundle := GenBundle([]schema.BundleItem{
+		{Tag: testTokenTag, From: "0x3d7e9dfbc58952fdacee2a5c69367c8478474d82", Amount: "1"},
+		{Tag: other, From: testSignerAddr, Amount: "2"},
+		{Tag: testTokenTag, From: "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", Amount: "3"},
+		{Tag: testTokenTag, From: testSignerAddr, Amount: "4"},
+	}, time.Now().Unix()+100)
+
+	tags, spends := BundleSpends(bundle, testSignerAddr)
+	assert.Equal(t, []string{testTokenTag, other}, tags)
+	assert.Equal(t, []schema.BundleItem{bundle.Items[0], bundle.Items[3]}, spends[testTokenTag])
+	assert.Equal(t, []schema.BundleItem{bundle.Items[1]}, spends[other])
+}
+
+func TestPolicyEngine_TimeWindows(t *testing.T) {
+	p, err := NewPolicyEngine(schema.Policy{
+		Timezone: "Asia/Shanghai",
+		TimeWindows: []schema.TimeWindow{
+			{Start: "09:00", End: "18:00", Weekdays: []string{"Mon", "Tue", "Wed", "Thu", "Fri"}},
+			{Start: "22:00", End: "02:00", Weekdays: []string{"Sat"}},
+		},
+	})
+	assert.NoError(t, err)
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	check := func(tm time.Time) error {
+		p.now = func() time.Time { return tm }
+		return p.Check(testTokenTag, schema.TxActionTransfer, "", "1", "0")
+	}
+
+	assert.NoError(t, check(time.Date(2023, 1, 2, 9, 0, 0, 0, loc)))       // Mon
+	assert.NoError(t, check(time.Date(2023, 1, 2, 1, 30, 0, 0, time.UTC))) // Mon 09:30 in Shanghai
+	assertViolation(t, check(time.Date(2023, 1, 2, 18, 0, 0, 0, loc)), schema.PolicyRuleTimeWindow)
+	assertViolation(t, check(time.Date(2023, 1, 7, 10, 0, 0, 0, loc)), schema.PolicyRuleTimeWindow) // Sat
+	assert.NoError(t, check(time.Date(2023, 1, 7, 23, 0, 0, 0, loc)))                               // Sat night
+	assert.NoError(t, check(time.Date(2023, 1, 8, 1, 0, 0, 0, loc)))                                // Sat overnight
+	assertViolation(t, check(time.Date(2023, 1, 8, 23, 0, 0, 0, loc)), schema.PolicyRuleTimeWindow) // Sun night
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "policy.yaml")
+	assert.NoError(t, os.WriteFile(yamlPath, []byte(`
+forbiddenActions: [transferOwner]
+tokens:
+  `+testTokenTag+`:
+    maxAmount: "100"
+    velocityCount: 2
+    velocityWindow: 10m
+`), 0644))
+	p, err := LoadPolicy(yamlPath)
+	assert.NoError(t, err)
+	assertViolation(t, p.Check(testTokenTag, schema.TxActionTransferOwner, "", "0", "0"), schema.PolicyRuleForbiddenAction)
+	assert.Equal(t, "100", p.tokens[testTokenTag].maxAmount.String())
+	assert.Equal(t, 10*time.Minute, p.tokens[testTokenTag].velocityWindow)
+
+	jsonPath := filepath.Join(dir, "policy.json")
+	assert.NoError(t, os.WriteFile(jsonPath, []byte(`{"tokens":{"`+testTokenTag+`":{"maxAmount":"-1"}}}`), 0644))
+	_, err = LoadPolicy(jsonPath)
+	assert.ErrorIs(t, err, schema.ERR_INVALID_AMOUNT)
+}
+
+func TestSDK_Policy(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	s := srv.newSDK(t)
+
+	p, err := NewPolicyEngine(schema.Policy{
+		Tokens: map[string]schema.TokenPolicy{testTokenTag: {DailyLimit: "100"}},
+	})
+	assert.NoError(t, err)
+	sink := &testAuditSink{}
+	assert.NoError(t, s.SetPolicy(p))
+	s.SetAuditSink(sink)
+
+	// token not listed by everPay
+	unknown, err := NewPolicyEngine(schema.Policy{
+		Tokens: map[string]schema.TokenPolicy{"bsc-usdc-0x8ac76a51cc950d9822d68b83fe1ad97b32cd580d": {DailyLimit: "100"}},
+	})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, s.SetPolicy(unknown), schema.ERR_TOKEN_NOT_EXIST)
+
+	to := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	_, err = s.Transfer(testTokenTag, big.NewInt(60), to, "")
+	assert.NoError(t, err)
+	_, err = s.Transfer(testTokenTag, big.NewInt(60), to, "")
+	assertViolation(t, err, schema.PolicyRuleDailyLimit)
+	assert.Equal(t, 1, len(srv.submitted()))
+
+	decisions := make([]schema.AuditRecord, 0)
+	for _, r := range sink.records {
+		if r.Event == schema.AuditEventPolicy {
+			decisions = append(decisions, r)
+		}
+	}
+	assert.Equal(t, 2, len(decisions))
+	assert.Equal(t, schema.PolicyDecisionAllow, decisions[0].Decision)
+	assert.Equal(t, schema.PolicyDecisionDeny, decisions[1].Decision)
+	assert.Equal(t, schema.PolicyRuleDailyLimit, decisions[1].Rule)
+
+	// bundle items from AccId are checked
+	bundle := GenBundle([]schema.BundleItem{{Tag: testTokenTag, From: s.AccId, To: to, Amount: "50"}}, time.Now().Unix()+100)
+	_, err = s.SignBundleData(bundle)
+	assertViolation(t, err, schema.PolicyRuleDailyLimit)
+
+	// items under the limit one by one are denied together
+	p, err = NewPolicyEngine(schema.Policy{
+		Tokens: map[string]schema.TokenPolicy{testTokenTag: {DailyLimit: "100"}},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, s.SetPolicy(p))
+	bundle = GenBundle([]schema.BundleItem{
+		{Tag: testTokenTag, From: s.AccId, To: to, Amount: "60"},
+		{Tag: testTokenTag, From: s.AccId, To: to, Amount: "60"},
+	}, time.Now().Unix()+100)
+	_, err = s.SignBundleData(bundle)
+	assertViolation(t, err, schema.PolicyRuleDailyLimit)
+	last := sink.records[len(sink.records)-1]
+	assert.Equal(t, schema.PolicyDecisionDeny, last.Decision)
+	assert.Equal(t, "120", last.Amount)
+
+	bundle.Items = bundle.Items[:1]
+	_, err = s.SignBundleData(bundle)
+	assert.NoError(t, err)
+	_, err = s.SignBundleData(bundle)
+	assertViolation(t, err, schema.PolicyRuleDailyLimit)
+}
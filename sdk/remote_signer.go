@@ -0,0 +1,105 @@
+package sdk
+
+import (
+	"encoding/json"
+
+	"github.com/everVision/everpay-kits/schema"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/plugins/body"
+)
+
+// RemoteSigner sign tx and bundle by remote signer daemon, the key never leaves the daemon
+type RemoteSigner struct {
+	Address    string // everPay account of daemon key
+	SignerType string // signer type of daemon key
+
+	cli *gentleman.Client
+}
+
+// NewRemoteSigner url: signer daemon url; token: auth token of daemon
+func NewRemoteSigner(url, token string) (*RemoteSigner, error) {
+	r := &RemoteSigner{
+		cli: gentleman.New().URL(url),
+	}
+	r.cli.SetHeader("Authorization", "Bearer "+token)
+
+	req := r.cli.Request()
+	req.Path("/signer")
+	res, err := req.Send()
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+	if !res.Ok {
+		return nil, decodeRemoteSignErr(res.Bytes())
+	}
+	info := schema.RemoteSignerInfo{}
+	if err = json.Unmarshal(res.Bytes(), &info); err != nil {
+		return nil, err
+	}
+	r.Address = info.Address
+	r.SignerType = info.SignerType
+	return r, nil
+}
+
+// SignTx tx.Sig is ignored, return sig of tx.String()
+func (r *RemoteSigner) SignTx(tx schema.Transaction) (string, error) {
+	tx.Sig = ""
+	return r.sign("/sign/tx", schema.RemoteSignTxReq{Tx: tx}, tx.HexHash())
+}
+
+func (r *RemoteSigner) SignBundle(bundle schema.Bundle) (string, error) {
+	return r.sign("/sign/bundle", schema.RemoteSignBundleReq{Bundle: bundle}, bundle.HashHex())
+}
+
+func (r *RemoteSigner) sign(path string, data interface{}, hash string) (string, error) {
+	req := r.cli.Request()
+	req.Path(path)
+	req.Method("POST")
+	req.Use(body.JSON(data))
+
+	res, err := req.Send()
+	if err != nil {
+		return "", err
+	}
+	defer res.Close()
+	if !res.Ok {
+		return "", decodeRemoteSignErr(res.Bytes())
+	}
+	resp := schema.RemoteSignResp{}
+	if err = json.Unmarshal(res.Bytes(), &resp); err != nil {
+		return "", err
+	}
+	// daemon must sign the same preimage
+	if resp.Hash != hash {
+		return "", schema.ERR_SIGN_HASH_MISMATCH
+	}
+	return resp.Sig, nil
+}
+
+// decodeRemoteSignErr return schema.PolicyViolation if tx denied by daemon policy
+func decodeRemoteSignErr(errMsg []byte) error {
+	resErr := schema.RespPolicyErr{}
+	if err := json.Unmarshal(errMsg, &resErr); err != nil {
+		return decodeRespErr(errMsg)
+	}
+	if resErr.Violation != nil {
+		return *resErr.Violation
+	}
+	return schema.RespErr{Err: resErr.Err}
+}
+
+// signTx sign tx by remote signer or local signer
+func (s *SDK) signTx(everTx schema.Transaction) (string, error) {
+	if s.signerType == RemoteSignerType {
+		return s.signer.(*RemoteSigner).SignTx(everTx)
+	}
+	return s.Sign(everTx.String())
+}
+
+func (s *SDK) signBundle(bundle schema.Bundle) (string, error) {
+	if s.signerType == RemoteSignerType {
+		return s.signer.(*RemoteSigner).SignBundle(bundle)
+	}
+	return s.Sign(bundle.String())
+}
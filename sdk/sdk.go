@@ -20,17 +20,24 @@ import (
 var log = common.NewLog("sdk")
 
 type SDK struct {
-	Info   schema.Info
-	tokens map[string]schema.TokenInfo // tag -> TokenInfo
+	Info     schema.Info
+	tokens   map[string]schema.TokenInfo // tag -> TokenInfo
+	registry *TokenRegistry
 
 	signerType string // ecc, rsa
 	signer     interface{}
 
 	AccId string
 	Cli   *Client
+	Fees  *FeeEstimator
 
 	lastNonce    int64 // last everTx used nonce
 	sendTxLocker sync.Mutex
+
+	balanceTracker *BalanceTracker
+	policy         *PolicyEngine
+	audit          AuditSink
+	ownerSalts     OwnerChallengeStore // used salts of owner challenges
 }
 
 func New(signer interface{}, payUrl string) (*SDK, error) {
@@ -39,13 +46,17 @@ func New(signer interface{}, payUrl string) (*SDK, error) {
 		return nil, err
 	}
 
+	cli := NewClient(payUrl)
 	sdk := &SDK{
+		registry:     NewTokenRegistry(nil),
 		signer:       signer,
 		signerType:   signerType,
 		AccId:        signerAddr,
-		Cli:          NewClient(payUrl),
+		Cli:          cli,
+		Fees:         NewFeeEstimator(cli, defaultFeeTTL),
 		lastNonce:    time.Now().UnixNano() / 1000000,
 		sendTxLocker: sync.Mutex{},
+		ownerSalts:   NewMemoryOwnerChallengeStore(),
 	}
 	err = sdk.updatePayInfo()
 	if err != nil {
@@ -79,6 +90,7 @@ func (s *SDK) updatePayInfo() error {
 		tokens[t.Tag] = t
 	}
 	s.tokens = tokens
+	s.registry.Update(info.TokenList)
 	s.Info = info
 	return nil
 }
@@ -87,14 +99,12 @@ func (s *SDK) GetTokens() map[string]schema.TokenInfo {
 	return s.tokens
 }
 
+func (s *SDK) TokenRegistry() *TokenRegistry {
+	return s.registry
+}
+
 func (s *SDK) SymbolToTagArr(symbol string) []string {
-	tagArr := make([]string, 0)
-	for tag, tok := range s.tokens {
-		if strings.ToUpper(tok.Symbol) == strings.ToUpper(symbol) {
-			tagArr = append(tagArr, tag)
-		}
-	}
-	return tagArr
+	return s.registry.TagsBySymbol(symbol)
 }
 
 func (s *SDK) Transfer(tokenTag string, amount *big.Int, to, data string) (*schema.Transaction, error) {
@@ -110,6 +120,39 @@ func (s *SDK) Withdraw(tokenTag string, amount *big.Int, chainType, to string) (
 	return s.sendBurnTx(tokenTag, chainType, to, amount, "")
 }
 
+// TransferWithQuote transfer with the pinned fee of quote, the tx will be rejected by everPay if fee changed
+func (s *SDK) TransferWithQuote(quote schema.FeeQuote, amount *big.Int, to, data string) (*schema.Transaction, error) {
+	if err := checkQuote(quote, schema.TxActionTransfer); err != nil {
+		return nil, err
+	}
+	if utils.IsEmailAddress(to) {
+		to = utils.GenEverId(to)
+	}
+	tokenInfo, ok := s.tokens[quote.TokenTag]
+	if !ok {
+		return nil, schema.ERR_TOKEN_NOT_EXIST
+	}
+	return s.sendTx(tokenInfo, schema.TxActionTransfer, quote.Fee, to, amount, data)
+}
+
+// WithdrawWithQuote withdraw to quote.TargetChainType with the pinned fee of quote
+func (s *SDK) WithdrawWithQuote(quote schema.FeeQuote, amount *big.Int, to string) (*schema.Transaction, error) {
+	if err := checkQuote(quote, schema.TxActionBurn); err != nil {
+		return nil, err
+	}
+	return s.sendBurnTxWithFee(quote.TokenTag, quote.TargetChainType, to, amount, "", quote.Fee)
+}
+
+func checkQuote(quote schema.FeeQuote, action string) error {
+	if quote.Action != action {
+		return schema.ERR_FEE_QUOTE_MISMATCH
+	}
+	if quote.Expired() {
+		return schema.ERR_FEE_QUOTE_EXPIRED
+	}
+	return nil
+}
+
 func (s *SDK) Deposit(tokenTag string, amount *big.Int, chainType, to, txData string) (*schema.Transaction, error) {
 	return s.sendMintTx(tokenTag, chainType, to, amount, txData)
 }
@@ -148,6 +191,11 @@ func (s *SDK) AddWhiteListTx(tokenTag string, whiteList []string) (*schema.Trans
 	return s.sendTx(tokenInfo, schema.TxActionAddWhiteList, "0", s.AccId, big.NewInt(0), data)
 }
 
+// AddWhiteListTxs ids already in white list are skipped, large list is split into several txs under data limit
+func (s *SDK) AddWhiteListTxs(tokenTag string, whiteList []string) ([]schema.ListChunkResult, error) {
+	return s.sendListChunked(tokenTag, schema.TxActionAddWhiteList, "whiteList", whiteList, s.Cli.WhiteList)
+}
+
 func (s *SDK) RemoveWhiteListTx(tokenTag string, whiteList []string) (*schema.Transaction, error) {
 	tokenInfo, ok := s.tokens[tokenTag]
 	if !ok {
@@ -160,6 +208,11 @@ func (s *SDK) RemoveWhiteListTx(tokenTag string, whiteList []string) (*schema.Tr
 	return s.sendTx(tokenInfo, schema.TxActionRemoveWhiteList, "0", s.AccId, big.NewInt(0), data)
 }
 
+// RemoveWhiteListTxs ids not in white list are skipped, large list is split into several txs under data limit
+func (s *SDK) RemoveWhiteListTxs(tokenTag string, whiteList []string) ([]schema.ListChunkResult, error) {
+	return s.sendListChunked(tokenTag, schema.TxActionRemoveWhiteList, "whiteList", whiteList, s.Cli.WhiteList)
+}
+
 func (s *SDK) PauseWhiteListTx(tokenTag string, pause bool) (*schema.Transaction, error) {
 	tokenInfo, ok := s.tokens[tokenTag]
 	if !ok {
@@ -184,6 +237,11 @@ func (s *SDK) AddBlackListTx(tokenTag string, blackList []string) (*schema.Trans
 	return s.sendTx(tokenInfo, schema.TxActionAddBlackList, "0", s.AccId, big.NewInt(0), data)
 }
 
+// AddBlackListTxs ids already in black list are skipped, large list is split into several txs under data limit
+func (s *SDK) AddBlackListTxs(tokenTag string, blackList []string) ([]schema.ListChunkResult, error) {
+	return s.sendListChunked(tokenTag, schema.TxActionAddBlackList, "blackList", blackList, s.Cli.BlackList)
+}
+
 func (s *SDK) RemoveBlackListTx(tokenTag string, blackList []string) (*schema.Transaction, error) {
 	tokenInfo, ok := s.tokens[tokenTag]
 	if !ok {
@@ -196,6 +254,11 @@ func (s *SDK) RemoveBlackListTx(tokenTag string, blackList []string) (*schema.Tr
 	return s.sendTx(tokenInfo, schema.TxActionRemoveBlackList, "0", s.AccId, big.NewInt(0), data)
 }
 
+// RemoveBlackListTxs ids not in black list are skipped, large list is split into several txs under data limit
+func (s *SDK) RemoveBlackListTxs(tokenTag string, blackList []string) ([]schema.ListChunkResult, error) {
+	return s.sendListChunked(tokenTag, schema.TxActionRemoveBlackList, "blackList", blackList, s.Cli.BlackList)
+}
+
 func (s *SDK) PauseBlackListTx(tokenTag string, pause bool) (*schema.Transaction, error) {
 	tokenInfo, ok := s.tokens[tokenTag]
 	if !ok {
@@ -257,29 +320,33 @@ func (s *SDK) sendTransfer(tokenTag string, receiver string, amount *big.Int, da
 }
 
 func (s *SDK) sendBurnTx(tokenTag string, targetChainType, receiver string, amount *big.Int, data string) (*schema.Transaction, error) {
-	tokenInfo, ok := s.tokens[tokenTag]
-	if !ok {
-		return nil, schema.ERR_TOKEN_NOT_EXIST
-	}
-	action := schema.TxActionBurn
-	tFee, err := s.Cli.Fee(tokenTag)
+	quote, err := s.Fees.Quote(schema.TxActionBurn, tokenTag, targetChainType)
 	if err != nil {
 		return nil, err
 	}
-	fee, ok := tFee.Fee.BurnFeeMap[targetChainType]
-	if !ok {
-		return nil, schema.ERR_BURN_FEE_NOT_EXIST
+	everTx, err := s.sendBurnTxWithFee(tokenTag, targetChainType, receiver, amount, data, quote.Fee)
+	if !isFeeErr(err) {
+		return everTx, err
 	}
-	if data != "" && !gjson.Valid(data) {
-		return nil, schema.ERR_NOT_JSON_DATA
+	// cached fee is outdated, retry once with the latest fee
+	s.Fees.Invalidate(tokenTag)
+	quote, err = s.Fees.Quote(schema.TxActionBurn, tokenTag, targetChainType)
+	if err != nil {
+		return nil, err
 	}
+	return s.sendBurnTxWithFee(tokenTag, targetChainType, receiver, amount, data, quote.Fee)
+}
 
-	// add targetChainType in data
-	txData, err := sjson.Set(data, "targetChainType", targetChainType)
+func (s *SDK) sendBurnTxWithFee(tokenTag string, targetChainType, receiver string, amount *big.Int, data, fee string) (*schema.Transaction, error) {
+	tokenInfo, ok := s.tokens[tokenTag]
+	if !ok {
+		return nil, schema.ERR_TOKEN_NOT_EXIST
+	}
+	txData, err := targetChainData(data, targetChainType)
 	if err != nil {
 		return nil, err
 	}
-	return s.sendTx(tokenInfo, action, fee, receiver, amount, txData)
+	return s.sendTx(tokenInfo, schema.TxActionBurn, fee, receiver, amount, txData)
 }
 
 func (s *SDK) sendMintTx(tokenTag string, targetChainType, receiver string, amount *big.Int, data string) (*schema.Transaction, error) {
@@ -287,17 +354,21 @@ func (s *SDK) sendMintTx(tokenTag string, targetChainType, receiver string, amou
 	if !ok {
 		return nil, schema.ERR_TOKEN_NOT_EXIST
 	}
-	if data != "" && !gjson.Valid(data) {
-		return nil, schema.ERR_NOT_JSON_DATA
-	}
-	// add targetChainType in data
-	txData, err := sjson.Set(data, "targetChainType", targetChainType)
+	txData, err := targetChainData(data, targetChainType)
 	if err != nil {
 		return nil, err
 	}
 	return s.sendTx(tokenInfo, schema.TxActionMint, "0", receiver, amount, txData)
 }
 
+// targetChainData add targetChainType in json data of burn and mint tx
+func targetChainData(data, targetChainType string) (string, error) {
+	if data != "" && !gjson.Valid(data) {
+		return "", schema.ERR_NOT_JSON_DATA
+	}
+	return sjson.Set(data, "targetChainType", targetChainType)
+}
+
 func (s *SDK) sendBundle(tokenTag string, receiver string, amount *big.Int, bundle schema.BundleData) (*schema.Transaction, error) {
 	tokenInfo, ok := s.tokens[tokenTag]
 	if !ok {
@@ -317,11 +388,16 @@ func (s *SDK) sendBundle(tokenTag string, receiver string, amount *big.Int, bund
 func (s *SDK) sendTx(tokenInfo schema.TokenInfo, action, fee, receiver string, amount *big.Int, data string) (*schema.Transaction, error) {
 	s.sendTxLocker.Lock()
 	defer s.sendTxLocker.Unlock()
+	everTx := s.assembleTx(tokenInfo, action, fee, receiver, amount, data)
+	return s.signAndSubmitTx(tokenInfo.Tag, everTx)
+}
+
+// assembleTx return tx without sig, must be called with sendTxLocker
+func (s *SDK) assembleTx(tokenInfo schema.TokenInfo, action, fee, receiver string, amount *big.Int, data string) schema.Transaction {
 	if amount == nil {
 		amount = big.NewInt(0)
 	}
-	// assemble tx
-	everTx := schema.Transaction{
+	return schema.Transaction{
 		TokenSymbol:  tokenInfo.Symbol,
 		Action:       action,
 		From:         s.AccId,
@@ -337,20 +413,39 @@ func (s *SDK) sendTx(tokenInfo schema.TokenInfo, action, fee, receiver string, a
 		Version:      schema.TxVersionV1,
 		Sig:          "",
 	}
+}
+
+// signAndSubmitTx check policy, sign and submit tx, must be called with sendTxLocker
+func (s *SDK) signAndSubmitTx(tokenTag string, everTx schema.Transaction) (*schema.Transaction, error) {
+	if err := s.checkPolicy(tokenTag, everTx.Action, everTx.To, everTx.Amount, everTx.Fee, everTx.Nonce); err != nil {
+		return &everTx, err
+	}
 
-	sign, err := s.Sign(everTx.String())
+	sign, err := s.signTx(everTx)
 	if err != nil {
 		log.Error("Sign failed", "error", err)
 		return &everTx, err
 	}
 	everTx.Sig = sign
+	if err := s.auditSign(tokenTag, &everTx); err != nil {
+		log.Error("audit signed everTx failed", "error", err)
+		return &everTx, err
+	}
 
 	// submit to everpay server
-	if err := s.Cli.SubmitTx(everTx); err != nil {
+	err = s.Cli.SubmitTx(everTx)
+	s.auditSubmit(tokenTag, &everTx, err)
+	if err != nil {
 		log.Error("submit everTx", "error", err)
 		return &everTx, err
 	}
 
+	if s.policy != nil {
+		s.policy.Record(tokenTag, everTx.Amount, everTx.Fee)
+	}
+	if s.balanceTracker != nil {
+		s.balanceTracker.AddPending(&everTx)
+	}
 	return &everTx, nil
 }
 
@@ -367,10 +462,41 @@ func GenBundle(items []schema.BundleItem, expiration int64) schema.Bundle {
 }
 
 func (s *SDK) SignBundleData(bundleTx schema.Bundle) (schema.BundleWithSigs, error) {
-	sign, err := s.Sign(bundleTx.String())
+	// only the items spent from AccId are checked by policy, items of the same token are checked as one spend
+	tags, spends := BundleSpends(bundleTx, s.AccId)
+	// policy is checked and recorded with sendTxLocker, the same as txs
+	s.sendTxLocker.Lock()
+	defer s.sendTxLocker.Unlock()
+	for _, tag := range tags {
+		if err := s.checkBundlePolicy(tag, bundleTx.Salt, spends[tag]); err != nil {
+			return schema.BundleWithSigs{}, err
+		}
+	}
+
+	sign, err := s.signBundle(bundleTx)
 	if err != nil {
 		return schema.BundleWithSigs{}, err
 	}
+	if s.audit != nil {
+		record := schema.AuditRecord{
+			Time:     time.Now().UnixMilli(),
+			Event:    schema.AuditEventSign,
+			Signer:   s.AccId,
+			TokenTag: strings.Join(tags, ","),
+			Action:   schema.TxActionBundle,
+			Nonce:    bundleTx.Salt,
+			Preimage: bundleTx.String(),
+			EverHash: bundleTx.HashHex(),
+		}
+		if err = s.audit.Append(record); err != nil {
+			return schema.BundleWithSigs{}, err
+		}
+	}
+	if s.policy != nil {
+		for _, tag := range tags {
+			s.policy.RecordBundle(tag, spends[tag])
+		}
+	}
 	return schema.BundleWithSigs{
 		Bundle: bundleTx,
 		Sigs: map[string]string{
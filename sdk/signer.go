@@ -4,21 +4,39 @@ import (
 	"crypto/sha256"
 	"errors"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/everFinance/goar"
 	"github.com/everFinance/goar/utils"
 	"github.com/everFinance/goether"
+	"github.com/everVision/everpay-kits/schema"
+	everUtils "github.com/everVision/everpay-kits/utils"
 )
 
 const (
-	RSASignerType = "RSASigner"
-	EccSignerType = "EccSigner"
+	RSASignerType    = "RSASigner"
+	EccSignerType    = "EccSigner"
+	EverIdSignerType = "EverIdSigner"
+	RemoteSignerType = "RemoteSigner"
 )
 
 func (s *SDK) Sign(msg string) (string, error) {
-	switch s.signerType {
+	return signMsg(s.signerType, s.signer, msg)
+}
+
+// SignMsg sign msg with *goar.Signer, *goether.Signer or *EverIdSigner, return everPay sig
+func SignMsg(signer interface{}, msg string) (string, error) {
+	signerType, _, err := reflectSigner(signer)
+	if err != nil {
+		return "", err
+	}
+	return signMsg(signerType, signer, msg)
+}
+
+func signMsg(signerType string, s interface{}, msg string) (string, error) {
+	switch signerType {
 	case RSASignerType:
-		signer := s.signer.(*goar.Signer)
+		signer := s.(*goar.Signer)
 		hash := sha256.Sum256([]byte(msg))
 		sig, err := signer.SignMsg(hash[:])
 		if err != nil {
@@ -26,17 +44,27 @@ func (s *SDK) Sign(msg string) (string, error) {
 		}
 		return utils.Base64Encode(sig) + "," + signer.Owner(), nil
 	case EccSignerType:
-		signer := s.signer.(*goether.Signer)
+		signer := s.(*goether.Signer)
 		sig, err := signer.SignMsg([]byte(msg))
 		if err != nil {
 			return "", err
 		}
 		return hexutil.Encode(sig), nil
+	case EverIdSignerType:
+		signer := s.(*EverIdSigner)
+		return signer.Sign(msg)
+	case RemoteSignerType:
+		return "", schema.ERR_SIGN_MSG_NOT_SUPPORT
 	default:
 		return "", errors.New("not found signer")
 	}
 }
 
+// SignerInfo return the signer type and everPay account of signer
+func SignerInfo(signer interface{}) (signerType string, signerAddr string, err error) {
+	return reflectSigner(signer)
+}
+
 func reflectSigner(signer interface{}) (signerType string, signerAddr string, err error) {
 	if s, ok := signer.(*goar.Signer); ok {
 		signerType = RSASignerType
@@ -48,6 +76,87 @@ func reflectSigner(signer interface{}) (signerType string, signerAddr string, er
 		signerAddr = s.Address.String()
 		return
 	}
+	if s, ok := signer.(*EverIdSigner); ok {
+		signerType = EverIdSignerType
+		signerAddr = s.EverId
+		return
+	}
+	if s, ok := signer.(*RemoteSigner); ok {
+		signerType = RemoteSignerType
+		signerAddr = s.Address
+		return
+	}
 	err = errors.New("not support this signer")
 	return
 }
+
+// EverIdSigner signs for an everId (eid) account with an ecc or rsa key bound to it.
+// sig format: sig + "," + base64(public) + "," + publicType
+type EverIdSigner struct {
+	EverId string
+	Email  string
+
+	publicType string
+	signer     interface{} // *goether.Signer or *goar.Signer
+}
+
+// NewEverIdSigner signer must be *goether.Signer or *goar.Signer
+func NewEverIdSigner(email string, signer interface{}) (*EverIdSigner, error) {
+	if !everUtils.IsEmailAddress(email) {
+		return nil, schema.ERR_INVALID_EMAIL
+	}
+
+	var publicType string
+	switch signer.(type) {
+	case *goether.Signer:
+		publicType = schema.EVMPublicType
+	case *goar.Signer:
+		publicType = schema.ArPublicType
+	default:
+		return nil, errors.New("not support this signer")
+	}
+
+	return &EverIdSigner{
+		EverId:     everUtils.GenEverId(email),
+		Email:      email,
+		publicType: publicType,
+		signer:     signer,
+	}, nil
+}
+
+// PublicType return "ECDSA" or "RSA"
+func (e *EverIdSigner) PublicType() string {
+	return e.publicType
+}
+
+// Public return base64 encoded public key, ecc: uncompressed public key; rsa: public modulus
+func (e *EverIdSigner) Public() string {
+	switch e.publicType {
+	case schema.EVMPublicType:
+		return utils.Base64Encode(e.signer.(*goether.Signer).GetPublicKey())
+	default:
+		return e.signer.(*goar.Signer).Owner()
+	}
+}
+
+func (e *EverIdSigner) Sign(msg string) (string, error) {
+	var sig string
+	switch e.publicType {
+	case schema.EVMPublicType:
+		sigBy, err := e.signer.(*goether.Signer).SignMsg([]byte(msg))
+		if err != nil {
+			return "", err
+		}
+		sig = hexutil.Encode(sigBy)
+	case schema.ArPublicType:
+		// eid rsa sig is verified with the everHash, not with the arHash
+		sigBy, err := e.signer.(*goar.Signer).SignMsg(accounts.TextHash([]byte(msg)))
+		if err != nil {
+			return "", err
+		}
+		sig = utils.Base64Encode(sigBy)
+	default:
+		return "", schema.ERR_ACC_TYPE_NOT_SUPPORT
+	}
+	return sig + "," + e.Public() + "," + e.publicType, nil
+}
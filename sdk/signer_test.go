@@ -0,0 +1,69 @@
+package sdk
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/everFinance/goar"
+	"github.com/everFinance/goether"
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func testEverIdTx(from string) schema.Transaction {
+	return schema.Transaction{
+		TokenSymbol:  "USDT",
+		Action:       schema.TxActionTransfer,
+		From:         from,
+		To:           "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223",
+		Amount:       "100",
+		Fee:          "0",
+		FeeRecipient: "0x6451eB7f668de69Fb4C943Db72bCF2A73DeeC6B1",
+		Nonce:        "1700000000000",
+		TokenID:      "0xd85476c906b5301e8e9eb58d174a6f96b9dfc5ee",
+		ChainType:    schema.ChainTypeEth,
+		ChainID:      "5",
+		Data:         "",
+		Version:      schema.TxVersionV1,
+	}
+}
+
+func TestEverIdSigner_Ecc(t *testing.T) {
+	ecc, err := goether.NewSigner("ad1dcf8f1c449e7af21a7b8341eba5f053055819fff9948f1251ea94a0184cae")
+	assert.NoError(t, err)
+	signer, err := NewEverIdSigner("test@everpay.io", ecc)
+	assert.NoError(t, err)
+	assert.Equal(t, utils.GenEverId("test@everpay.io"), signer.EverId)
+	assert.Equal(t, schema.EVMPublicType, signer.PublicType())
+
+	tx := testEverIdTx(signer.EverId)
+	tx.Sig, err = signer.Sign(tx.String())
+	assert.NoError(t, err)
+
+	_, err = utils.Verify(schema.AccountTypeEverId, signer.EverId, tx.Sig, tx.Hash(), 5)
+	assert.NoError(t, err)
+}
+
+func TestEverIdSigner_RSA(t *testing.T) {
+	prv, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	signer, err := NewEverIdSigner("test@everpay.io", goar.NewSignerByPrivateKey(prv))
+	assert.NoError(t, err)
+	assert.Equal(t, schema.ArPublicType, signer.PublicType())
+
+	tx := testEverIdTx(signer.EverId)
+	tx.Sig, err = signer.Sign(tx.String())
+	assert.NoError(t, err)
+
+	_, err = utils.Verify(schema.AccountTypeEverId, signer.EverId, tx.Sig, tx.Hash(), 5)
+	assert.NoError(t, err)
+}
+
+func TestNewEverIdSigner_InvalidEmail(t *testing.T) {
+	ecc, err := goether.NewSigner("ad1dcf8f1c449e7af21a7b8341eba5f053055819fff9948f1251ea94a0184cae")
+	assert.NoError(t, err)
+	_, err = NewEverIdSigner("everpay.io", ecc)
+	assert.Equal(t, schema.ERR_INVALID_EMAIL, err)
+}
@@ -37,12 +37,7 @@ func (s *SubscribeTx) run() {
 		t1.Reset(interval)
 		select {
 		case <-t1.C:
-			txs, err = s.client.Txs(cursorId, orderBy, limit, schema.TxOpts{
-				Address:       s.filterQuery.Address,
-				TokenTag:      s.filterQuery.TokenTag,
-				Action:        s.filterQuery.Action,
-				WithoutAction: s.filterQuery.WithoutAction,
-			})
+			txs, err = s.client.Txs(cursorId, orderBy, limit, s.filterQuery.TxOpts())
 
 			if err != nil {
 				interval = 10 * time.Second
@@ -50,12 +45,16 @@ func (s *SubscribeTx) run() {
 			}
 
 			for _, tx := range txs.Txs {
-				s.ch <- tx
+				select {
+				case s.ch <- tx:
+				case <-s.quit:
+					log.Debug("Unsubscribe txs")
+					return
+				}
 			}
 
-			num := len(txs.Txs)
-			if num > 0 {
-				cursorId = txs.Txs[num-1].RawId
+			if txs.NextCursor > 0 {
+				cursorId = txs.NextCursor
 				interval = 1 * time.Second
 			} else {
 				interval = 5 * time.Second
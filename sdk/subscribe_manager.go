@@ -0,0 +1,206 @@
+package sdk
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/tidwall/gjson"
+)
+
+// SubscribeManager poll the global tx stream once and dispatch txs to the watched addresses
+type SubscribeManager struct {
+	sub *SubscribeTx
+
+	lock     sync.RWMutex
+	watchers map[string]map[*AddrSubscriber]struct{} // key: address formatted by utils.FormatAccId
+	cursor   int64                                   // rawId of the last tx delivered to all matching subscribers
+
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+// AddrSubscriber receive the txs of one watched address
+type AddrSubscriber struct {
+	manager   *SubscribeManager
+	address   string
+	tokenTags []string
+	// handle deliver tx synchronously instead of ch, return false to stop dispatch
+	handle func(tx schema.TxResponse, quit <-chan struct{}) bool
+
+	ch       chan schema.TxResponse
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+// NewSubscribeManager fq.Address, fq.From and fq.To are ignored, other filters apply to all watched addresses
+func (c *Client) NewSubscribeManager(fq schema.FilterQuery) *SubscribeManager {
+	fq.Address, fq.From, fq.To = "", "", ""
+	m := &SubscribeManager{
+		sub:      c.SubscribeTxs(fq),
+		watchers: make(map[string]map[*AddrSubscriber]struct{}),
+		cursor:   fq.StartCursor,
+		quit:     make(chan struct{}),
+	}
+	go m.run()
+	return m
+}
+
+// Watch subscribe the txs of address, tokenTags: option, only receive txs of these tokens
+// a slow subscriber will block the dispatch of all subscribers
+func (m *SubscribeManager) Watch(address string, tokenTags ...string) *AddrSubscriber {
+	return m.watch(address, nil, tokenTags...)
+}
+
+func (m *SubscribeManager) watch(address string, handle func(tx schema.TxResponse, quit <-chan struct{}) bool, tokenTags ...string) *AddrSubscriber {
+	s := &AddrSubscriber{
+		manager:   m,
+		address:   utils.FormatAccId(address),
+		tokenTags: tokenTags,
+		handle:    handle,
+		ch:        make(chan schema.TxResponse),
+		quit:      make(chan struct{}),
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.watchers[s.address]; !ok {
+		m.watchers[s.address] = make(map[*AddrSubscriber]struct{})
+	}
+	m.watchers[s.address][s] = struct{}{}
+	return s
+}
+
+// Addresses return all watched addresses, EVM address is checksummed, AR and eid address are case sensitive
+func (m *SubscribeManager) Addresses() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	addrs := make([]string, 0, len(m.watchers))
+	for addr := range m.watchers {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	return addrs
+}
+
+// Cursor return the rawId of the last tx delivered to all matching subscribers, can be used as StartCursor to resume
+func (m *SubscribeManager) Cursor() int64 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.cursor
+}
+
+// Close stop polling and unsubscribe all subscribers
+func (m *SubscribeManager) Close() {
+	m.quitOnce.Do(func() {
+		close(m.quit)
+		m.sub.Unsubscribe()
+	})
+}
+
+func (m *SubscribeManager) remove(s *AddrSubscriber) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	subs, ok := m.watchers[s.address]
+	if !ok {
+		return
+	}
+	delete(subs, s)
+	if len(subs) == 0 {
+		delete(m.watchers, s.address)
+	}
+}
+
+func (m *SubscribeManager) run() {
+	for {
+		select {
+		case tx := <-m.sub.Subscribe():
+			m.dispatch(tx)
+		case <-m.quit:
+			log.Debug("SubscribeManager closed")
+			return
+		}
+	}
+}
+
+func (m *SubscribeManager) dispatch(tx schema.TxResponse) {
+	for addr, tags := range txAddrTags(tx) {
+		m.lock.RLock()
+		subs := make([]*AddrSubscriber, 0, len(m.watchers[addr]))
+		for s := range m.watchers[addr] {
+			subs = append(subs, s)
+		}
+		m.lock.RUnlock()
+
+		for _, s := range subs {
+			if !s.matchTags(tags) {
+				continue
+			}
+			if s.handle != nil {
+				if !s.handle(tx, s.quit) {
+					return
+				}
+				continue
+			}
+			select {
+			case s.ch <- tx:
+			case <-s.quit:
+			case <-m.quit:
+				return
+			}
+		}
+	}
+
+	m.lock.Lock()
+	m.cursor = tx.RawId
+	m.lock.Unlock()
+}
+
+// txAddrTags return the addresses involved in tx and their token tags
+// bundle tx include the from and to of every bundle item
+func txAddrTags(tx schema.TxResponse) map[string][]string {
+	res := make(map[string][]string)
+	add := func(addr, tag string) {
+		addr = utils.FormatAccId(addr)
+		if addr == "" || schema.ContainsStr(res[addr], tag, true) {
+			return
+		}
+		res[addr] = append(res[addr], tag)
+	}
+
+	tag := tx.Tag()
+	add(tx.From, tag)
+	add(tx.To, tag)
+	if tx.Action == schema.TxActionBundle {
+		for _, item := range gjson.Get(tx.Data, "bundle.items").Array() {
+			itemTag := item.Get("tag").String()
+			add(item.Get("from").String(), itemTag)
+			add(item.Get("to").String(), itemTag)
+		}
+	}
+	return res
+}
+
+func (s *AddrSubscriber) matchTags(tags []string) bool {
+	if len(s.tokenTags) == 0 {
+		return true
+	}
+	for _, tag := range tags {
+		if schema.ContainsStr(s.tokenTags, tag, true) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *AddrSubscriber) Subscribe() <-chan schema.TxResponse {
+	return s.ch
+}
+
+func (s *AddrSubscriber) Unsubscribe() {
+	s.quitOnce.Do(func() {
+		close(s.quit)
+		s.manager.remove(s)
+	})
+}
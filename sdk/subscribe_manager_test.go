@@ -0,0 +1,110 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeManager(t *testing.T) {
+	addr01 := "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82"
+	addr02 := "0xf392A4e8DDbfBD7782407561B8Beab911c36d59A"
+	txs := append(genTestTxs(3, addr01), genTestTxs(2, addr02)...)
+	for i := range txs {
+		txs[i].RawId = int64(i + 1)
+	}
+	srv := newTestPayServer()
+	srv.txResps = txs
+	defer srv.Close()
+
+	m := NewClient(srv.URL).NewSubscribeManager(schema.FilterQuery{})
+	defer m.Close()
+	sub01 := m.Watch(addr01)
+	sub02 := m.Watch(addr02)
+	assert.Equal(t, 2, len(m.Addresses()))
+
+	received := map[string]int{}
+	timeout := time.After(5 * time.Second)
+	for received[addr01]+received[addr02] < 5 {
+		select {
+		case tx := <-sub01.Subscribe():
+			assert.Equal(t, addr01, tx.From)
+			received[addr01]++
+		case tx := <-sub02.Subscribe():
+			assert.Equal(t, addr02, tx.From)
+			received[addr02]++
+		case <-timeout:
+			t.Fatal("subscribe timeout")
+		}
+	}
+	assert.Equal(t, 3, received[addr01])
+	assert.Equal(t, 2, received[addr02])
+	assert.Eventually(t, func() bool { return m.Cursor() == 5 }, time.Second, 10*time.Millisecond)
+
+	sub01.Unsubscribe()
+	assert.Equal(t, []string{addr02}, m.Addresses())
+}
+
+func TestSubscribeManager_Cursor(t *testing.T) {
+	addr := "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82"
+	txs := append(genTestTxs(1, "0xf392A4e8DDbfBD7782407561B8Beab911c36d59A"), genTestTxs(2, addr)...)
+	for i := range txs {
+		txs[i].RawId = int64(i + 1)
+	}
+	srv := newTestPayServer()
+	srv.txResps = txs
+	defer srv.Close()
+
+	m := NewClient(srv.URL).NewSubscribeManager(schema.FilterQuery{})
+	defer m.Close()
+	sub := m.Watch(addr)
+
+	// not advanced to tx 2 until it is received
+	assert.Eventually(t, func() bool { return m.Cursor() == 1 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int64(1), m.Cursor())
+	select {
+	case tx := <-sub.Subscribe():
+		assert.Equal(t, int64(2), tx.RawId)
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscribe timeout")
+	}
+	assert.Eventually(t, func() bool { return m.Cursor() == 2 }, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int64(2), m.Cursor())
+}
+
+func TestSubscribeManager_CaseSensitiveAddr(t *testing.T) {
+	// ar addresses only differ in case
+	ar01 := "cSYOy8-p1QFenktkDBFyRM3cwZSTrQ_J4EsELLho_UE"
+	ar02 := "csyoy8-p1qfenktkdbfyrm3cwzstrq_j4esellho_ue"
+	evm := "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82"
+	txs := append(genTestTxs(1, ar01), genTestTxs(1, evm)...)
+	txs[1].RawId = 2
+	srv := newTestPayServer()
+	srv.txResps = txs
+	defer srv.Close()
+
+	m := NewClient(srv.URL).NewSubscribeManager(schema.FilterQuery{})
+	defer m.Close()
+	sub01 := m.Watch(ar01)
+	sub02 := m.Watch(ar02)
+	subEvm := m.Watch("0x3d7e9dfbc58952fdacee2a5c69367c8478474d82")
+	assert.Equal(t, []string{evm, ar01, ar02}, m.Addresses())
+
+	timeout := time.After(5 * time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case tx := <-sub01.Subscribe():
+			assert.Equal(t, ar01, tx.From)
+		case tx := <-subEvm.Subscribe():
+			assert.Equal(t, evm, tx.From)
+		case <-sub02.Subscribe():
+			t.Fatal("tx of other ar address")
+		case <-timeout:
+			t.Fatal("subscribe timeout")
+		}
+	}
+}
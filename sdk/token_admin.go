@@ -0,0 +1,259 @@
+package sdk
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+)
+
+// LoadTokenStates load desired token states from yaml (.yaml, .yml) or json file, key: tokenTag
+func LoadTokenStates(path string) (map[string]schema.TokenState, error) {
+	states := make(map[string]schema.TokenState)
+	err := loadConfigFile(path, &states)
+	return states, err
+}
+
+// PlanTokenAdmin diff desired states with live states from everPay, return the minimal admin txs.
+// steps of each token: pause token, black list, white list, pause lists, unpause token, transfer owner
+func (s *SDK) PlanTokenAdmin(desired map[string]schema.TokenState) (schema.AdminPlan, error) {
+	plan := schema.AdminPlan{Steps: make([]schema.AdminStep, 0)}
+	info, err := s.Cli.GetInfo()
+	if err != nil {
+		return plan, err
+	}
+	live := make(map[string]schema.TokenInfo, len(info.TokenList))
+	for _, t := range info.TokenList {
+		live[t.Tag] = t
+	}
+
+	tags := make([]string, 0, len(desired))
+	for tag := range desired {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		tokenInfo, ok := live[tag]
+		if !ok {
+			return plan, fmt.Errorf("%w: %s", schema.ERR_TOKEN_NOT_EXIST, tag)
+		}
+		extra := tokenInfo.TNS102Extra
+		if extra == nil {
+			return plan, fmt.Errorf("%w: %s", schema.ERR_NOT_TNS102_TOKEN, tag)
+		}
+		if utils.FormatAccId(extra.Owner) != s.AccId {
+			return plan, fmt.Errorf("%w: %s owner is %s", schema.ERR_INVALID_OWNER, tag, extra.Owner)
+		}
+		steps, err := s.planToken(tag, desired[tag], *extra)
+		if err != nil {
+			return plan, err
+		}
+		plan.Steps = append(plan.Steps, steps...)
+	}
+	return plan, nil
+}
+
+func (s *SDK) planToken(tag string, want schema.TokenState, extra schema.Tns102Extra) ([]schema.AdminStep, error) {
+	steps := make([]schema.AdminStep, 0)
+	pauseStep := func(action string, want *bool, current bool) {
+		if want != nil && *want != current {
+			steps = append(steps, schema.AdminStep{TokenTag: tag, Action: action, Pause: *want})
+		}
+	}
+
+	// pause token first and unpause last, keep token paused during changes
+	if want.Pause != nil && *want.Pause && !extra.Pause {
+		pauseStep(schema.TxActionPause, want.Pause, extra.Pause)
+	}
+	if want.BlackList != nil {
+		current, err := s.Cli.BlackList(tag)
+		if err != nil {
+			return nil, err
+		}
+		add, remove, err := diffList(want.BlackList, current)
+		if err != nil {
+			return nil, err
+		}
+		if len(remove) > 0 {
+			steps = append(steps, schema.AdminStep{TokenTag: tag, Action: schema.TxActionRemoveBlackList, List: remove})
+		}
+		if len(add) > 0 {
+			steps = append(steps, schema.AdminStep{TokenTag: tag, Action: schema.TxActionAddBlackList, List: add})
+		}
+	}
+	if want.WhiteList != nil {
+		current, err := s.Cli.WhiteList(tag)
+		if err != nil {
+			return nil, err
+		}
+		add, remove, err := diffList(want.WhiteList, current)
+		if err != nil {
+			return nil, err
+		}
+		if len(remove) > 0 {
+			steps = append(steps, schema.AdminStep{TokenTag: tag, Action: schema.TxActionRemoveWhiteList, List: remove})
+		}
+		if len(add) > 0 {
+			steps = append(steps, schema.AdminStep{TokenTag: tag, Action: schema.TxActionAddWhiteList, List: add})
+		}
+	}
+	pauseStep(schema.TxActionPauseBlackList, want.PauseBlackList, extra.PauseBlackList)
+	pauseStep(schema.TxActionPauseWhiteList, want.PauseWhiteList, extra.PauseWhiteList)
+	if want.Pause != nil && !*want.Pause && extra.Pause {
+		pauseStep(schema.TxActionPause, want.Pause, extra.Pause)
+	}
+
+	if want.Owner != "" {
+		_, owner, err := utils.IDCheck(want.Owner)
+		if err != nil {
+			return nil, fmt.Errorf("%w: owner %s", err, want.Owner)
+		}
+		if owner != utils.FormatAccId(extra.Owner) {
+			if want.OwnerChallenge == nil || want.OwnerProof == "" {
+				return nil, fmt.Errorf("%w: %s ownerChallenge and ownerProof are required", schema.ERR_INVALID_OWNER_PROOF, tag)
+			}
+			if err = s.verifyOwnerProof(tag, owner, *want.OwnerChallenge, want.OwnerProof); err != nil {
+				return nil, fmt.Errorf("%w: %s", err, tag)
+			}
+			steps = append(steps, schema.AdminStep{
+				TokenTag:  tag,
+				Action:    schema.TxActionTransferOwner,
+				Owner:     owner,
+				Challenge: want.OwnerChallenge,
+				Proof:     want.OwnerProof,
+			})
+		}
+	}
+	return steps, nil
+}
+
+// ApplyTokenAdmin submit the admin txs of plan in order, stop at the first failed step.
+// the live owner is checked before each step, the pause steps already in live state are skipped
+func (s *SDK) ApplyTokenAdmin(plan schema.AdminPlan) ([]schema.AdminStepResult, error) {
+	results := make([]schema.AdminStepResult, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		everHashes := []string{}
+		skip, err := s.checkAdminStep(step)
+		if err == nil && !skip {
+			everHashes, err = s.applyAdminStep(step)
+		}
+		res := schema.AdminStepResult{Step: step, EverHashes: everHashes}
+		if err != nil {
+			res.Error = err.Error()
+			results = append(results, res)
+			log.Error("apply admin step failed", "step", step.String(), "err", err)
+			return results, err
+		}
+		results = append(results, res)
+		log.Info("admin step applied", "step", step.String(), "skipped", skip, "everHashes", everHashes)
+	}
+	return results, nil
+}
+
+func (s *SDK) applyAdminStep(step schema.AdminStep) ([]string, error) {
+	var (
+		tx  *schema.Transaction
+		err error
+	)
+	switch step.Action {
+	case schema.TxActionAddWhiteList, schema.TxActionRemoveWhiteList, schema.TxActionAddBlackList, schema.TxActionRemoveBlackList:
+		var chunks []schema.ListChunkResult
+		switch step.Action {
+		case schema.TxActionAddWhiteList:
+			chunks, err = s.AddWhiteListTxs(step.TokenTag, step.List)
+		case schema.TxActionRemoveWhiteList:
+			chunks, err = s.RemoveWhiteListTxs(step.TokenTag, step.List)
+		case schema.TxActionAddBlackList:
+			chunks, err = s.AddBlackListTxs(step.TokenTag, step.List)
+		default:
+			chunks, err = s.RemoveBlackListTxs(step.TokenTag, step.List)
+		}
+		everHashes := make([]string, 0, len(chunks))
+		for _, c := range chunks {
+			if c.Err == nil {
+				everHashes = append(everHashes, c.Tx.HexHash())
+			}
+		}
+		return everHashes, err
+	case schema.TxActionPauseWhiteList:
+		tx, err = s.PauseWhiteListTx(step.TokenTag, step.Pause)
+	case schema.TxActionPauseBlackList:
+		tx, err = s.PauseBlackListTx(step.TokenTag, step.Pause)
+	case schema.TxActionPause:
+		tx, err = s.PauseTokenTx(step.TokenTag, step.Pause)
+	case schema.TxActionTransferOwner:
+		if step.Challenge == nil {
+			return nil, schema.ERR_INVALID_OWNER_PROOF
+		}
+		tx, err = s.TransferTokenOwner(step.TokenTag, step.Owner, OwnerTransferOpts{
+			RequireProof: true,
+			Challenge:    *step.Challenge,
+			Proof:        step.Proof,
+		})
+	default:
+		return nil, fmt.Errorf("not support admin action: %s", step.Action)
+	}
+	if tx != nil && (err == nil || errors.Is(err, schema.ERR_OWNER_NOT_CONFIRMED)) {
+		return []string{tx.HexHash()}, err
+	}
+	return nil, err
+}
+
+// checkAdminStep check sdk signer is still the live owner of token, skip is true if pause step is already applied
+func (s *SDK) checkAdminStep(step schema.AdminStep) (skip bool, err error) {
+	extra, err := s.tokenExtra(step.TokenTag)
+	if err != nil {
+		return false, err
+	}
+	if utils.FormatAccId(extra.Owner) != s.AccId {
+		return false, fmt.Errorf("%w: %s owner is %s", schema.ERR_INVALID_OWNER, step.TokenTag, extra.Owner)
+	}
+	switch step.Action {
+	case schema.TxActionPauseWhiteList:
+		return extra.PauseWhiteList == step.Pause, nil
+	case schema.TxActionPauseBlackList:
+		return extra.PauseBlackList == step.Pause, nil
+	case schema.TxActionPause:
+		return extra.Pause == step.Pause, nil
+	}
+	return false, nil
+}
+
+// diffList return the ids in want but not in current, and in current but not in want; ids are normalized by utils.IDCheck
+func diffList(want, current []string) (add, remove []string, err error) {
+	wantSet := make(map[string]bool, len(want))
+	for _, id := range want {
+		_, accId, err := utils.IDCheck(id)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %s", err, id)
+		}
+		if wantSet[accId] {
+			continue
+		}
+		wantSet[accId] = true
+		add = append(add, accId)
+	}
+
+	currentSet := make(map[string]bool, len(current))
+	for _, id := range current {
+		if _, accId, err := utils.IDCheck(id); err == nil {
+			id = accId
+		}
+		currentSet[id] = true
+		if !wantSet[id] {
+			remove = append(remove, id)
+		}
+	}
+
+	n := 0
+	for _, id := range add {
+		if !currentSet[id] {
+			add[n] = id
+			n++
+		}
+	}
+	add = add[:n]
+	return
+}
@@ -0,0 +1,127 @@
+package sdk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenAdmin_PlanApply(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.extra = schema.Tns102Extra{Owner: testSignerAddr, PauseWhiteList: true}
+	srv.whiteList = []string{"0x4002ed1a1410af1b4930cf6c479ae373debd6223", "0x61EbF673c200646236B2c53465bcA0699455d5FA"}
+	s := srv.newSDK(t)
+
+	yes, no := true, false
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tokens.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(testTokenTag+`:
+  whiteList:
+    - "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+    - "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82"
+  blackList:
+    - "0x2ca81e1253f9426c62Df68b39a22A377164eeC92"
+  pauseWhiteList: false
+`), 0644))
+	states, err := LoadTokenStates(path)
+	assert.NoError(t, err)
+	assert.Equal(t, &no, states[testTokenTag].PauseWhiteList)
+
+	plan, err := s.PlanTokenAdmin(states)
+	assert.NoError(t, err)
+	assert.Equal(t, []schema.AdminStep{
+		{TokenTag: testTokenTag, Action: schema.TxActionAddBlackList, List: []string{"0x2ca81e1253f9426c62Df68b39a22A377164eeC92"}},
+		{TokenTag: testTokenTag, Action: schema.TxActionRemoveWhiteList, List: []string{"0x61EbF673c200646236B2c53465bcA0699455d5FA"}},
+		{TokenTag: testTokenTag, Action: schema.TxActionAddWhiteList, List: []string{"0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82"}},
+		{TokenTag: testTokenTag, Action: schema.TxActionPauseWhiteList, Pause: false},
+	}, plan.Steps)
+
+	results, err := s.ApplyTokenAdmin(plan)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, len(results))
+	assert.Equal(t, []string{srv.submitted()[0].HexHash()}, results[0].EverHashes)
+
+	// converged
+	plan, err = s.PlanTokenAdmin(states)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(plan.Steps))
+
+	// owner proof is required
+	newSigner := newTestEccSigner(t)
+	newOwner := newSigner.Address.String()
+	_, err = s.PlanTokenAdmin(map[string]schema.TokenState{testTokenTag: {Owner: newOwner}})
+	assert.ErrorIs(t, err, schema.ERR_INVALID_OWNER_PROOF)
+	challenge, err := s.NewOwnerChallenge(testTokenTag, newOwner, 0)
+	assert.NoError(t, err)
+	_, err = s.PlanTokenAdmin(map[string]schema.TokenState{testTokenTag: {
+		Owner: newOwner, OwnerChallenge: &challenge, OwnerProof: mustSignMsg(t, newTestEccSigner(t), challenge.String()),
+	}})
+	assert.ErrorIs(t, err, schema.ERR_INVALID_OWNER_PROOF)
+
+	// pause first, owner last
+	plan, err = s.PlanTokenAdmin(map[string]schema.TokenState{testTokenTag: {
+		Pause:          &yes,
+		BlackList:      []string{},
+		Owner:          newOwner,
+		OwnerChallenge: &challenge,
+		OwnerProof:     mustSignMsg(t, newSigner, challenge.String()),
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(plan.Steps))
+	assert.Equal(t, schema.TxActionPause, plan.Steps[0].Action)
+	assert.Equal(t, schema.TxActionRemoveBlackList, plan.Steps[1].Action)
+	assert.Equal(t, schema.TxActionTransferOwner, plan.Steps[2].Action)
+	_, err = s.ApplyTokenAdmin(plan)
+	assert.NoError(t, err)
+
+	assert.Equal(t, newOwner, srv.owner())
+
+	// not owner any more
+	_, err = s.PlanTokenAdmin(states)
+	assert.ErrorIs(t, err, schema.ERR_INVALID_OWNER)
+}
+
+func TestTokenAdmin_ApplyLiveState(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.extra = schema.Tns102Extra{Owner: testSignerAddr}
+	s := srv.newSDK(t)
+
+	yes := true
+	plan, err := s.PlanTokenAdmin(map[string]schema.TokenState{testTokenTag: {
+		Pause: &yes, PauseWhiteList: &yes, BlackList: []string{"0x2ca81e1253f9426c62Df68b39a22A377164eeC92"},
+	}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(plan.Steps))
+
+	// pause step already applied by others is skipped
+	srv.update(func(p *testPayServer) { p.extra.Pause = true })
+	results, err := s.ApplyTokenAdmin(schema.AdminPlan{Steps: plan.Steps[:1]})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, results[0].EverHashes)
+	assert.Equal(t, 0, len(srv.submitted()))
+
+	// owner changed after plan
+	srv.update(func(p *testPayServer) { p.extra.Owner = "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223" })
+	results, err = s.ApplyTokenAdmin(plan)
+	assert.ErrorIs(t, err, schema.ERR_INVALID_OWNER)
+	assert.Equal(t, 1, len(results))
+	assert.NotEmpty(t, results[0].Error)
+	assert.Equal(t, 0, len(srv.submitted()))
+}
+
+func TestDiffList(t *testing.T) {
+	add, remove, err := diffList(
+		[]string{"0x4002ed1a1410af1b4930cf6c479ae373debd6223", "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"},
+		[]string{"0x61EbF673c200646236B2c53465bcA0699455d5FA"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"}, add)
+	assert.Equal(t, []string{"0x61EbF673c200646236B2c53465bcA0699455d5FA"}, remove)
+
+	_, _, err = diffList([]string{"invalid"}, nil)
+	assert.ErrorIs(t, err, schema.ERR_INVALID_ID)
+}
@@ -0,0 +1,212 @@
+package sdk
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultOwnerChallengeTTL    = 10 * time.Minute
+	defaultOwnerConfirmTimeout  = time.Minute
+	defaultOwnerConfirmInterval = 3 * time.Second
+)
+
+// OwnerTransferOpts Challenge and Proof are required if RequireProof,
+// Challenge: created by NewOwnerChallenge, Proof: newOwner signed Challenge.String() by sdk signer.
+// the used challenges are recorded by OwnerChallengeStore, which is in memory by default,
+// so a challenge is rejected once used only until restart unless the store is persisted
+type OwnerTransferOpts struct {
+	RequireProof bool
+	Challenge    schema.OwnerChallenge
+	Proof        string
+
+	ConfirmTimeout  time.Duration // default 1 minute
+	ConfirmInterval time.Duration // default 3 seconds
+}
+
+// NewOwnerChallenge return the challenge for newOwner to sign, ttl default 10 minutes
+func (s *SDK) NewOwnerChallenge(tokenTag, newOwner string, ttl time.Duration) (schema.OwnerChallenge, error) {
+	_, newOwner, err := utils.IDCheck(newOwner)
+	if err != nil {
+		return schema.OwnerChallenge{}, err
+	}
+	if ttl <= 0 {
+		ttl = defaultOwnerChallengeTTL
+	}
+	return schema.OwnerChallenge{
+		TokenTag:   tokenTag,
+		Owner:      s.AccId,
+		NewOwner:   newOwner,
+		Expiration: time.Now().Add(ttl).Unix(),
+		Salt:       uuid.NewString(),
+	}, nil
+}
+
+// TransferTokenOwner transfer TNS-102 token owner after checking newOwner and the proof of control,
+// then wait until everPay token owner is newOwner. the tx is returned with ERR_OWNER_NOT_CONFIRMED if timeout
+func (s *SDK) TransferTokenOwner(tokenTag, newOwner string, opts OwnerTransferOpts) (*schema.Transaction, error) {
+	_, newOwner, err := utils.IDCheck(newOwner)
+	if err != nil {
+		return nil, err
+	}
+	owner, err := s.tokenOwner(tokenTag)
+	if err != nil {
+		return nil, err
+	}
+	if utils.FormatAccId(owner) != s.AccId {
+		return nil, fmt.Errorf("%w: %s owner is %s", schema.ERR_INVALID_OWNER, tokenTag, owner)
+	}
+	if utils.FormatAccId(owner) == newOwner {
+		return nil, fmt.Errorf("%w: %s is already owner", schema.ERR_INVALID_OWNER, newOwner)
+	}
+	if opts.RequireProof {
+		if err = s.verifyOwnerProof(tokenTag, newOwner, opts.Challenge, opts.Proof); err != nil {
+			return nil, err
+		}
+		// the challenge is consumed even if tx failed, a new challenge is required to retry
+		ok, err := s.challengeStore().UseSalt(opts.Challenge.Salt, opts.Challenge.Expiration)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, schema.ERR_OWNER_CHALLENGE_USED
+		}
+	}
+
+	tx, err := s.TransferTokenOwnerTx(tokenTag, newOwner)
+	if err != nil {
+		return tx, err
+	}
+	return tx, s.waitTokenOwner(tokenTag, newOwner, opts)
+}
+
+func (s *SDK) verifyOwnerProof(tokenTag, newOwner string, c schema.OwnerChallenge, proof string) error {
+	if c.TokenTag != tokenTag || utils.FormatAccId(c.Owner) != s.AccId || utils.FormatAccId(c.NewOwner) != newOwner {
+		return schema.ERR_INVALID_OWNER_PROOF
+	}
+	if time.Now().Unix() > c.Expiration {
+		return schema.ERR_OWNER_CHALLENGE_EXPIRED
+	}
+	used, err := s.challengeStore().SaltUsed(c.Salt)
+	if err != nil {
+		return err
+	}
+	if used {
+		return schema.ERR_OWNER_CHALLENGE_USED
+	}
+	chainID, _ := strconv.Atoi(s.Info.EthChainID)
+	if err = utils.VerifyMsg(newOwner, c.String(), proof, chainID); err != nil {
+		log.Warn("verify owner proof failed", "tokenTag", tokenTag, "newOwner", newOwner, "err", err)
+		return schema.ERR_INVALID_OWNER_PROOF
+	}
+	return nil
+}
+
+// tokenOwner return the owner of TNS-102 token from everPay
+func (s *SDK) tokenOwner(tokenTag string) (string, error) {
+	extra, err := s.tokenExtra(tokenTag)
+	return extra.Owner, err
+}
+
+// tokenExtra return the live TNS-102 state of token from everPay
+func (s *SDK) tokenExtra(tokenTag string) (schema.Tns102Extra, error) {
+	info, err := s.Cli.GetInfo()
+	if err != nil {
+		return schema.Tns102Extra{}, err
+	}
+	for _, t := range info.TokenList {
+		if t.Tag != tokenTag {
+			continue
+		}
+		if t.TNS102Extra == nil {
+			return schema.Tns102Extra{}, schema.ERR_NOT_TNS102_TOKEN
+		}
+		return *t.TNS102Extra, nil
+	}
+	return schema.Tns102Extra{}, schema.ERR_TOKEN_NOT_EXIST
+}
+
+func (s *SDK) waitTokenOwner(tokenTag, newOwner string, opts OwnerTransferOpts) error {
+	timeout, interval := opts.ConfirmTimeout, opts.ConfirmInterval
+	if timeout <= 0 {
+		timeout = defaultOwnerConfirmTimeout
+	}
+	if interval <= 0 {
+		interval = defaultOwnerConfirmInterval
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		owner, err := s.tokenOwner(tokenTag)
+		if err == nil && utils.FormatAccId(owner) == newOwner {
+			return nil
+		}
+		if err != nil {
+			log.Warn("query token owner failed", "tokenTag", tokenTag, "err", err)
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return schema.ERR_OWNER_NOT_CONFIRMED
+		}
+		time.Sleep(interval)
+	}
+}
+
+// OwnerChallengeStore the used salts of owner challenges,
+// persist them to reject the used challenges after restart
+type OwnerChallengeStore interface {
+	// UseSalt mark salt used until expiration (unix second), return false if already used
+	UseSalt(salt string, expiration int64) (bool, error)
+	SaltUsed(salt string) (bool, error)
+}
+
+// SetOwnerChallengeStore replace the default MemoryOwnerChallengeStore
+func (s *SDK) SetOwnerChallengeStore(store OwnerChallengeStore) {
+	s.sendTxLocker.Lock()
+	s.ownerSalts = store
+	s.sendTxLocker.Unlock()
+}
+
+func (s *SDK) challengeStore() OwnerChallengeStore {
+	s.sendTxLocker.Lock()
+	defer s.sendTxLocker.Unlock()
+	return s.ownerSalts
+}
+
+// MemoryOwnerChallengeStore the used salts in memory with expiration, the expired salts are pruned
+// since the challenges are rejected after expiration
+type MemoryOwnerChallengeStore struct {
+	lock  sync.Mutex
+	salts map[string]int64 // salt -> expiration, unix second
+}
+
+func NewMemoryOwnerChallengeStore() *MemoryOwnerChallengeStore {
+	return &MemoryOwnerChallengeStore{salts: make(map[string]int64)}
+}
+
+func (m *MemoryOwnerChallengeStore) SaltUsed(salt string) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	_, ok := m.salts[salt]
+	return ok, nil
+}
+
+func (m *MemoryOwnerChallengeStore) UseSalt(salt string, expiration int64) (bool, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.salts[salt]; ok {
+		return false, nil
+	}
+	now := time.Now().Unix()
+	for k, exp := range m.salts {
+		if now > exp {
+			delete(m.salts, k)
+		}
+	}
+	m.salts[salt] = expiration
+	return true, nil
+}
@@ -0,0 +1,164 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferTokenOwner(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.extra = schema.Tns102Extra{Owner: testSignerAddr}
+	s := srv.newSDK(t)
+
+	newOwner := newTestEccSigner(t)
+	other := newTestEccSigner(t)
+	opts := OwnerTransferOpts{RequireProof: true, ConfirmTimeout: 50 * time.Millisecond, ConfirmInterval: 10 * time.Millisecond}
+
+	_, err := s.TransferTokenOwner(testTokenTag, "invalid", opts)
+	assert.Equal(t, schema.ERR_INVALID_ID, err)
+	_, err = s.TransferTokenOwner(testTokenTag, s.AccId, opts)
+	assert.ErrorIs(t, err, schema.ERR_INVALID_OWNER)
+
+	// proof required
+	_, err = s.TransferTokenOwner(testTokenTag, newOwner.Address.String(), opts)
+	assert.Equal(t, schema.ERR_INVALID_OWNER_PROOF, err)
+	challenge, err := s.NewOwnerChallenge(testTokenTag, newOwner.Address.String(), time.Minute)
+	assert.NoError(t, err)
+	opts.Challenge = challenge
+	opts.Proof, _ = SignMsg(other, challenge.String())
+	_, err = s.TransferTokenOwner(testTokenTag, newOwner.Address.String(), opts)
+	assert.Equal(t, schema.ERR_INVALID_OWNER_PROOF, err)
+
+	// challenge for other newOwner
+	opts.Proof, _ = SignMsg(newOwner, challenge.String())
+	_, err = s.TransferTokenOwner(testTokenTag, other.Address.String(), opts)
+	assert.Equal(t, schema.ERR_INVALID_OWNER_PROOF, err)
+
+	expired := challenge
+	expired.Expiration = time.Now().Unix() - 1
+	_, err = s.TransferTokenOwner(testTokenTag, newOwner.Address.String(), OwnerTransferOpts{
+		RequireProof: true, Challenge: expired, Proof: mustSignMsg(t, newOwner, expired.String()),
+	})
+	assert.Equal(t, schema.ERR_OWNER_CHALLENGE_EXPIRED, err)
+	assert.Equal(t, 0, len(srv.submitted()))
+
+	tx, err := s.TransferTokenOwner(testTokenTag, newOwner.Address.String(), opts)
+	assert.NoError(t, err)
+	assert.Equal(t, schema.TxActionTransferOwner, tx.Action)
+	assert.Equal(t, newOwner.Address.String(), srv.owner())
+
+	// not owner any more
+	_, err = s.TransferTokenOwner(testTokenTag, other.Address.String(), OwnerTransferOpts{})
+	assert.ErrorIs(t, err, schema.ERR_INVALID_OWNER)
+}
+
+func TestTransferTokenOwner_WithoutProof(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.extra = schema.Tns102Extra{Owner: testSignerAddr}
+	s := srv.newSDK(t)
+
+	newOwner := newTestEccSigner(t)
+	tx, err := s.TransferTokenOwner(testTokenTag, newOwner.Address.String(), OwnerTransferOpts{
+		ConfirmTimeout: 50 * time.Millisecond, ConfirmInterval: 10 * time.Millisecond,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, schema.TxActionTransferOwner, tx.Action)
+	assert.Equal(t, newOwner.Address.String(), srv.owner())
+}
+
+func TestTransferTokenOwner_NotConfirmed(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.extra = schema.Tns102Extra{Owner: testSignerAddr}
+	s := srv.newSDK(t)
+
+	// everPay accepted the tx but owner not changed
+	srv.update(func(p *testPayServer) { p.ignoreOwner = true })
+	newOwner := newTestEccSigner(t)
+	challenge, err := s.NewOwnerChallenge(testTokenTag, newOwner.Address.String(), 0)
+	assert.NoError(t, err)
+	tx, err := s.TransferTokenOwner(testTokenTag, newOwner.Address.String(), OwnerTransferOpts{
+		Challenge: challenge, Proof: mustSignMsg(t, newOwner, challenge.String()),
+		ConfirmTimeout: 30 * time.Millisecond, ConfirmInterval: 10 * time.Millisecond,
+	})
+	assert.Equal(t, schema.ERR_OWNER_NOT_CONFIRMED, err)
+	assert.NotNil(t, tx)
+	assert.Equal(t, 1, len(srv.submitted()))
+}
+
+func TestTransferTokenOwner_Replay(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	srv.extra = schema.Tns102Extra{Owner: testSignerAddr}
+	s := srv.newSDK(t)
+
+	newOwner := newTestEccSigner(t)
+	challenge, err := s.NewOwnerChallenge(testTokenTag, newOwner.Address.String(), 0)
+	assert.NoError(t, err)
+	opts := OwnerTransferOpts{
+		RequireProof: true, Challenge: challenge, Proof: mustSignMsg(t, newOwner, challenge.String()),
+		ConfirmTimeout: 30 * time.Millisecond, ConfirmInterval: 10 * time.Millisecond,
+	}
+	_, err = s.TransferTokenOwner(testTokenTag, newOwner.Address.String(), opts)
+	assert.NoError(t, err)
+
+	// owner transferred back, the used proof can not transfer again
+	srv.update(func(p *testPayServer) { p.extra.Owner = testSignerAddr })
+	_, err = s.TransferTokenOwner(testTokenTag, newOwner.Address.String(), opts)
+	assert.Equal(t, schema.ERR_OWNER_CHALLENGE_USED, err)
+	_, err = s.PlanTokenAdmin(map[string]schema.TokenState{testTokenTag: {
+		Owner: newOwner.Address.String(), OwnerChallenge: &opts.Challenge, OwnerProof: opts.Proof,
+	}})
+	assert.ErrorIs(t, err, schema.ERR_OWNER_CHALLENGE_USED)
+	assert.Equal(t, 1, len(srv.submitted()))
+
+	// consumed even if tx failed
+	challenge, err = s.NewOwnerChallenge(testTokenTag, newOwner.Address.String(), 0)
+	assert.NoError(t, err)
+	opts.Challenge, opts.Proof = challenge, mustSignMsg(t, newOwner, challenge.String())
+	srv.update(func(p *testPayServer) { p.submitErr = `{"error":"err_invalid_signature"}` })
+	_, err = s.TransferTokenOwner(testTokenTag, newOwner.Address.String(), opts)
+	assert.EqualError(t, err, "err_invalid_signature")
+	srv.update(func(p *testPayServer) { p.submitErr = "" })
+	_, err = s.TransferTokenOwner(testTokenTag, newOwner.Address.String(), opts)
+	assert.Equal(t, schema.ERR_OWNER_CHALLENGE_USED, err)
+
+	// used challenges kept by the store, e.g. restarted with persisted store
+	store := NewMemoryOwnerChallengeStore()
+	s.SetOwnerChallengeStore(store)
+	challenge, err = s.NewOwnerChallenge(testTokenTag, newOwner.Address.String(), 0)
+	assert.NoError(t, err)
+	opts.Challenge, opts.Proof = challenge, mustSignMsg(t, newOwner, challenge.String())
+	srv.update(func(p *testPayServer) { p.extra.Owner = testSignerAddr })
+	_, err = s.TransferTokenOwner(testTokenTag, newOwner.Address.String(), opts)
+	assert.NoError(t, err)
+	restarted := srv.newSDK(t)
+	restarted.SetOwnerChallengeStore(store)
+	srv.update(func(p *testPayServer) { p.extra.Owner = testSignerAddr })
+	_, err = restarted.TransferTokenOwner(testTokenTag, newOwner.Address.String(), opts)
+	assert.Equal(t, schema.ERR_OWNER_CHALLENGE_USED, err)
+}
+
+func TestMemoryOwnerChallengeStore(t *testing.T) {
+	store := NewMemoryOwnerChallengeStore()
+	now := time.Now().Unix()
+	ok, err := store.UseSalt("s1", now-1)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	used, _ := store.SaltUsed("s1")
+	assert.True(t, used)
+	ok, _ = store.UseSalt("s1", now+60)
+	assert.False(t, ok)
+	// expired salts are pruned
+	ok, _ = store.UseSalt("s2", now+60)
+	assert.True(t, ok)
+	used, _ = store.SaltUsed("s1")
+	assert.False(t, used)
+	used, _ = store.SaltUsed("s2")
+	assert.True(t, used)
+}
@@ -0,0 +1,146 @@
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/everVision/everpay-kits/schema"
+)
+
+// TokenRegistry lookup everPay tokens by tag, symbol, native token and target chain token
+type TokenRegistry struct {
+	lock   sync.RWMutex
+	tokens map[string]schema.TokenInfo // tag -> TokenInfo
+}
+
+func NewTokenRegistry(tokenList []schema.TokenInfo) *TokenRegistry {
+	r := &TokenRegistry{}
+	r.Update(tokenList)
+	return r
+}
+
+// Update replace all tokens, e.g. tokenList of Client.GetInfo
+func (r *TokenRegistry) Update(tokenList []schema.TokenInfo) {
+	tokens := make(map[string]schema.TokenInfo, len(tokenList))
+	for _, t := range tokenList {
+		tokens[t.Tag] = t
+	}
+	r.lock.Lock()
+	r.tokens = tokens
+	r.lock.Unlock()
+}
+
+// Tokens return all tokens sorted by tag
+func (r *TokenRegistry) Tokens() []schema.TokenInfo {
+	return r.filter(func(schema.TokenInfo) bool { return true })
+}
+
+func (r *TokenRegistry) Token(tag string) (schema.TokenInfo, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	tok, ok := r.tokens[tag]
+	if !ok {
+		return schema.TokenInfo{}, schema.ERR_TOKEN_NOT_EXIST
+	}
+	return tok, nil
+}
+
+// TagsBySymbol return tags of tokens with symbol, case-insensitive
+func (r *TokenRegistry) TagsBySymbol(symbol string) []string {
+	toks := r.filter(func(t schema.TokenInfo) bool {
+		return strings.EqualFold(t.Symbol, symbol)
+	})
+	tags := make([]string, 0, len(toks))
+	for _, t := range toks {
+		tags = append(tags, t.Tag)
+	}
+	return tags
+}
+
+// BySymbol return ERR_TOKEN_AMBIGUOUS if more than one token with symbol
+func (r *TokenRegistry) BySymbol(symbol string) (schema.TokenInfo, error) {
+	return onlyToken(symbol, r.filter(func(t schema.TokenInfo) bool {
+		return strings.EqualFold(t.Symbol, symbol)
+	}))
+}
+
+// ByNativeToken lookup by everPay chainType and native chain token id
+// e.g. ("ethereum", "0xdac17f958d2ee523a2206206994597c13d831ec7")
+func (r *TokenRegistry) ByNativeToken(chainType, tokenID string) (schema.TokenInfo, error) {
+	id, err := schema.FormatTokenID(chainType, tokenID)
+	if err != nil {
+		return schema.TokenInfo{}, err
+	}
+	return onlyToken(chainType+"-"+tokenID, r.filter(func(t schema.TokenInfo) bool {
+		if !strings.EqualFold(t.ChainType, chainType) {
+			return false
+		}
+		tokId, err := schema.FormatTokenID(t.ChainType, t.ID)
+		return err == nil && tokId == id
+	}))
+}
+
+// ByTargetChainToken lookup by targetChainType and token address on target chain in CrossChainInfoList,
+// arweave and aos token id is case-sensitive
+func (r *TokenRegistry) ByTargetChainToken(targetChainType, targetTokenID string) (schema.TokenInfo, error) {
+	id, err := schema.FormatTokenID(targetChainType, targetTokenID)
+	if err != nil {
+		return schema.TokenInfo{}, err
+	}
+	return onlyToken(targetChainType+"-"+targetTokenID, r.filter(func(t schema.TokenInfo) bool {
+		target, ok := t.CrossChainInfoList[targetChainType]
+		if !ok {
+			return false
+		}
+		tokId, err := schema.FormatTokenID(targetChainType, target.TokenId)
+		return err == nil && tokId == id
+	}))
+}
+
+// BySymbolOnTargetChain lookup by symbol and targetChainType, used to disambiguate symbol
+func (r *TokenRegistry) BySymbolOnTargetChain(symbol, targetChainType string) (schema.TokenInfo, error) {
+	return onlyToken(symbol+"-"+targetChainType, r.filter(func(t schema.TokenInfo) bool {
+		_, ok := t.CrossChainInfoList[targetChainType]
+		return ok && strings.EqualFold(t.Symbol, symbol)
+	}))
+}
+
+// TokensOnTargetChain return tokens which can cross to targetChainType
+func (r *TokenRegistry) TokensOnTargetChain(targetChainType string) []schema.TokenInfo {
+	return r.filter(func(t schema.TokenInfo) bool {
+		_, ok := t.CrossChainInfoList[targetChainType]
+		return ok
+	})
+}
+
+func (r *TokenRegistry) filter(match func(schema.TokenInfo) bool) []schema.TokenInfo {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	toks := make([]schema.TokenInfo, 0)
+	for _, t := range r.tokens {
+		if match(t) {
+			toks = append(toks, t)
+		}
+	}
+	sort.Slice(toks, func(i, j int) bool {
+		return toks[i].Tag < toks[j].Tag
+	})
+	return toks
+}
+
+func onlyToken(key string, toks []schema.TokenInfo) (schema.TokenInfo, error) {
+	switch len(toks) {
+	case 0:
+		return schema.TokenInfo{}, schema.ERR_TOKEN_NOT_EXIST
+	case 1:
+		return toks[0], nil
+	default:
+		tags := make([]string, 0, len(toks))
+		for _, t := range toks {
+			tags = append(tags, t.Tag)
+		}
+		return schema.TokenInfo{}, fmt.Errorf("%w: %s, tags: %s", schema.ERR_TOKEN_AMBIGUOUS, key, strings.Join(tags, " "))
+	}
+}
@@ -0,0 +1,86 @@
+package sdk
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+var testTokenList = []schema.TokenInfo{
+	{
+		Tag:       "arweave,ethereum-ar-AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA,0xcc9141efa8c20c7df0778748255b1487957811be",
+		ID:        "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA,0xcc9141efa8c20c7df0778748255b1487957811be",
+		Symbol:    "AR",
+		ChainType: schema.ChainTypeCrossArEth,
+		CrossChainInfoList: map[string]schema.TargetChain{
+			"arweave":  {ChainType: "arweave", Decimals: 12, TokenId: "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"},
+			"ethereum": {ChainType: "ethereum", Decimals: 12, TokenId: "0xcc9141efa8c20c7df0778748255b1487957811be"},
+		},
+	},
+	{
+		Tag:       "ethereum-usdt-0xdac17f958d2ee523a2206206994597c13d831ec7",
+		ID:        "0xdac17f958d2ee523a2206206994597c13d831ec7",
+		Symbol:    "USDT",
+		ChainType: schema.ChainTypeEth,
+		CrossChainInfoList: map[string]schema.TargetChain{
+			"ethereum": {ChainType: "ethereum", Decimals: 6, TokenId: "0xdac17f958d2ee523a2206206994597c13d831ec7"},
+		},
+	},
+	{
+		Tag:       "bsc-usdt-0x55d398326f99059ff775485246999027b3197955",
+		ID:        "0x55d398326f99059ff775485246999027b3197955",
+		Symbol:    "USDT",
+		ChainType: schema.ChainTypeBsc,
+		CrossChainInfoList: map[string]schema.TargetChain{
+			"bsc": {ChainType: "bsc", Decimals: 18, TokenId: "0x55d398326f99059ff775485246999027b3197955"},
+		},
+	},
+}
+
+func TestParseTag(t *testing.T) {
+	for _, tok := range testTokenList {
+		chainType, symbol, id, err := schema.ParseTag(tok.Tag)
+		assert.NoError(t, err)
+		assert.Equal(t, tok.ChainType, chainType)
+		assert.Equal(t, tok.Tag, schema.GenTag(chainType, symbol, id))
+	}
+
+	_, _, _, err := schema.ParseTag("ethereum-usdt")
+	assert.Equal(t, schema.ERR_INVALID_TAG, err)
+}
+
+func TestTokenRegistry(t *testing.T) {
+	r := NewTokenRegistry(testTokenList)
+
+	tok, err := r.BySymbol("ar")
+	assert.NoError(t, err)
+	assert.Equal(t, testTokenList[0].Tag, tok.Tag)
+
+	_, err = r.BySymbol("usdt")
+	assert.True(t, errors.Is(err, schema.ERR_TOKEN_AMBIGUOUS))
+	assert.Equal(t, 2, len(r.TagsBySymbol("USDT")))
+
+	tok, err = r.BySymbolOnTargetChain("usdt", "bsc")
+	assert.NoError(t, err)
+	assert.Equal(t, testTokenList[2].Tag, tok.Tag)
+
+	tok, err = r.ByNativeToken(schema.ChainTypeEth, "0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	assert.NoError(t, err)
+	assert.Equal(t, testTokenList[1].Tag, tok.Tag)
+
+	tok, err = r.ByTargetChainToken("arweave", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA")
+	assert.NoError(t, err)
+	assert.Equal(t, testTokenList[0].Tag, tok.Tag)
+	tok, err = r.ByTargetChainToken("ethereum", "0xCC9141EFA8C20C7DF0778748255B1487957811BE")
+	assert.NoError(t, err)
+	assert.Equal(t, testTokenList[0].Tag, tok.Tag)
+	// arweave token id is case-sensitive
+	_, err = r.ByTargetChainToken("arweave", "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	assert.Equal(t, schema.ERR_TOKEN_NOT_EXIST, err)
+
+	_, err = r.ByNativeToken(schema.ChainTypeEth, "0x0000000000000000000000000000000000000000")
+	assert.Equal(t, schema.ERR_TOKEN_NOT_EXIST, err)
+	assert.Equal(t, 2, len(r.TokensOnTargetChain("ethereum")))
+}
@@ -0,0 +1,328 @@
+package sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+	"gopkg.in/h2non/gentleman.v2"
+	"gopkg.in/h2non/gentleman.v2/plugins/timeout"
+)
+
+type WebhookEndpoint struct {
+	URL    string
+	Secret string // HMAC key
+	// Filter option, Address/TokenTag/Action/WithoutAction and the client side filters are all matched locally
+	Filter schema.TxOpts
+}
+
+type WebhookConfig struct {
+	Endpoints  []WebhookEndpoint
+	MaxRetries int           // default 5
+	Backoff    time.Duration // first retry delay, doubled every retry, default 1s
+	Timeout    time.Duration // request timeout, default 10s
+	QueueSize  int           // pending webhooks of each endpoint, default 1000, webhook is dead letter when queue is full
+}
+
+// DeadLetterStore store the webhooks failed after all retries
+type DeadLetterStore interface {
+	// SaveDeadLetter insert or update the dead letter of dl.URL and dl.RawId
+	SaveDeadLetter(dl schema.DeadLetter) error
+	DeadLetters() ([]schema.DeadLetter, error)
+	DeleteDeadLetter(url string, rawId int64) error
+}
+
+// WebhookDispatcher POST signed json webhooks of txs to endpoints,
+// every endpoint has its own queue and worker, a slow endpoint does not delay the others
+type WebhookDispatcher struct {
+	client *Client
+	cfg    WebhookConfig
+	store  DeadLetterStore
+	queues []chan schema.TxResponse // same index as cfg.Endpoints
+
+	wg       sync.WaitGroup
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+var errWebhookStopped = errors.New("webhook dispatcher stopped")
+
+func NewWebhookDispatcher(c *Client, cfg WebhookConfig, store DeadLetterStore) *WebhookDispatcher {
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if store == nil {
+		store = NewMemoryDeadLetterStore()
+	}
+	d := &WebhookDispatcher{
+		client: c,
+		cfg:    cfg,
+		store:  store,
+		queues: make([]chan schema.TxResponse, len(cfg.Endpoints)),
+		quit:   make(chan struct{}),
+	}
+	for i, ep := range cfg.Endpoints {
+		d.queues[i] = make(chan schema.TxResponse, cfg.QueueSize)
+		d.wg.Add(1)
+		go d.work(ep, d.queues[i])
+	}
+	return d
+}
+
+// Run dispatch txs from SubscribeTx.Subscribe() or AddrSubscriber.Subscribe() until txs closed or Stop
+func (d *WebhookDispatcher) Run(txs <-chan schema.TxResponse) {
+	for {
+		select {
+		case tx, ok := <-txs:
+			if !ok {
+				return
+			}
+			d.Dispatch(tx)
+		case <-d.quit:
+			return
+		}
+	}
+}
+
+// Stop the workers and wait the in-flight webhooks, queued webhooks are dropped
+func (d *WebhookDispatcher) Stop() {
+	d.quitOnce.Do(func() {
+		close(d.quit)
+	})
+	d.wg.Wait()
+}
+
+// Dispatch queue tx to all matched endpoints, the failed webhooks are saved to dead letter store
+func (d *WebhookDispatcher) Dispatch(tx schema.TxResponse) {
+	for i, ep := range d.cfg.Endpoints {
+		if !webhookMatch(ep.Filter, tx) {
+			continue
+		}
+		select {
+		case d.queues[i] <- tx:
+		default:
+			err := errors.New("webhook queue full")
+			log.Error("webhook failed", "url", ep.URL, "everHash", tx.EverHash, "err", err)
+			d.saveDeadLetter(d.newDeadLetter(ep, tx), 0, err)
+		}
+	}
+}
+
+func (d *WebhookDispatcher) work(ep WebhookEndpoint, queue <-chan schema.TxResponse) {
+	defer d.wg.Done()
+	for {
+		select {
+		case tx := <-queue:
+			attempts, err := d.deliver(ep, tx)
+			if err != nil {
+				log.Error("webhook failed", "url", ep.URL, "everHash", tx.EverHash, "err", err)
+				if err != errWebhookStopped {
+					d.saveDeadLetter(d.newDeadLetter(ep, tx), attempts, err)
+				}
+			}
+		case <-d.quit:
+			return
+		}
+	}
+}
+
+// Replay POST the tx of rawId again, to the dead letter endpoints if exist, otherwise to all matched endpoints
+// the dead letter is deleted if succeed, otherwise its Attempts and Error are updated
+func (d *WebhookDispatcher) Replay(rawId int64) error {
+	dls, err := d.store.DeadLetters()
+	if err != nil {
+		return err
+	}
+	deadLetters := make(map[string]schema.DeadLetter) // url -> dead letter
+	for _, dl := range dls {
+		if dl.RawId == rawId {
+			deadLetters[dl.URL] = dl
+		}
+	}
+
+	txs, err := d.client.Txs(rawId-1, OrderByAsc, 1, schema.TxOpts{})
+	if err != nil {
+		return err
+	}
+	if len(txs.Txs) == 0 || txs.Txs[0].RawId != rawId {
+		return fmt.Errorf("not found tx by rawId: %d", rawId)
+	}
+	tx := txs.Txs[0]
+	if len(deadLetters) == 0 {
+		d.Dispatch(tx)
+		return nil
+	}
+
+	var lastErr error
+	for _, ep := range d.cfg.Endpoints {
+		dl, ok := deadLetters[ep.URL]
+		if !ok {
+			continue
+		}
+		attempts, err := d.deliver(ep, tx)
+		if err != nil {
+			lastErr = err
+			if err != errWebhookStopped {
+				d.saveDeadLetter(dl, attempts, err)
+			}
+			continue
+		}
+		if err = d.store.DeleteDeadLetter(ep.URL, rawId); err != nil {
+			return err
+		}
+	}
+	return lastErr
+}
+
+// deliver POST tx to ep with retries, return the attempts and the last error
+func (d *WebhookDispatcher) deliver(ep WebhookEndpoint, tx schema.TxResponse) (attempts int, err error) {
+	payload, err := webhookPayload(tx)
+	if err != nil {
+		return
+	}
+
+	backoff := d.cfg.Backoff
+	for {
+		attempts++
+		if err = d.post(ep, payload); err == nil {
+			return
+		}
+		if attempts > d.cfg.MaxRetries {
+			return
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-d.quit:
+			return attempts, errWebhookStopped
+		}
+	}
+}
+
+func (d *WebhookDispatcher) newDeadLetter(ep WebhookEndpoint, tx schema.TxResponse) schema.DeadLetter {
+	payload, _ := webhookPayload(tx)
+	now := time.Now().Unix()
+	return schema.DeadLetter{
+		URL:       ep.URL,
+		RawId:     tx.RawId,
+		EverHash:  tx.EverHash,
+		Payload:   string(payload),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// saveDeadLetter add the failed attempts to dl and save it
+func (d *WebhookDispatcher) saveDeadLetter(dl schema.DeadLetter, attempts int, err error) {
+	dl.Attempts += attempts
+	dl.Error = err.Error()
+	dl.UpdatedAt = time.Now().Unix()
+	if dlErr := d.store.SaveDeadLetter(dl); dlErr != nil {
+		log.Error("save dead letter failed", "url", dl.URL, "rawId", dl.RawId, "err", dlErr)
+	}
+}
+
+func webhookPayload(tx schema.TxResponse) ([]byte, error) {
+	return json.Marshal(schema.WebhookEvent{
+		RawId:    tx.RawId,
+		EverHash: tx.EverHash,
+		Tx:       tx,
+	})
+}
+
+func (d *WebhookDispatcher) post(ep WebhookEndpoint, payload []byte) error {
+	ts := time.Now().Unix()
+	req := gentleman.New().URL(ep.URL).Request()
+	req.Method("POST")
+	req.Use(timeout.Request(d.cfg.Timeout))
+	req.SetHeader("Content-Type", "application/json")
+	req.SetHeader(schema.WebhookTimestampHeader, strconv.FormatInt(ts, 10))
+	req.SetHeader(schema.WebhookSignatureHeader, utils.SignWebhook(ep.Secret, ts, payload))
+	req.BodyString(string(payload))
+
+	res, err := req.Send()
+	if err != nil {
+		return err
+	}
+	defer res.Close()
+	if !res.Ok {
+		return fmt.Errorf("webhook response status: %d", res.StatusCode)
+	}
+	return nil
+}
+
+// webhookMatch match server side filters locally, then the client side filters
+func webhookMatch(opts schema.TxOpts, tx schema.TxResponse) bool {
+	if opts.Address != "" {
+		if _, ok := txAddrTags(tx)[utils.FormatAccId(opts.Address)]; !ok {
+			return false
+		}
+	}
+	if opts.TokenTag != "" && !strings.EqualFold(opts.TokenTag, tx.Tag()) {
+		return false
+	}
+	if opts.Action != "" && opts.Action != tx.Action {
+		return false
+	}
+	if opts.WithoutAction != "" && opts.WithoutAction == tx.Action {
+		return false
+	}
+	return opts.Match(tx)
+}
+
+type MemoryDeadLetterStore struct {
+	lock        sync.RWMutex
+	deadLetters []schema.DeadLetter
+}
+
+func NewMemoryDeadLetterStore() *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{}
+}
+
+func (m *MemoryDeadLetterStore) SaveDeadLetter(dl schema.DeadLetter) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for i := range m.deadLetters {
+		if m.deadLetters[i].URL == dl.URL && m.deadLetters[i].RawId == dl.RawId {
+			m.deadLetters[i] = dl
+			return nil
+		}
+	}
+	m.deadLetters = append(m.deadLetters, dl)
+	return nil
+}
+
+func (m *MemoryDeadLetterStore) DeadLetters() ([]schema.DeadLetter, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return append([]schema.DeadLetter{}, m.deadLetters...), nil
+}
+
+func (m *MemoryDeadLetterStore) DeleteDeadLetter(url string, rawId int64) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	dls := m.deadLetters[:0]
+	for _, dl := range m.deadLetters {
+		if dl.URL == url && dl.RawId == rawId {
+			continue
+		}
+		dls = append(dls, dl)
+	}
+	m.deadLetters = dls
+	return nil
+}
@@ -0,0 +1,173 @@
+package sdk
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDispatcher(t *testing.T) {
+	addr := "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82"
+	txs := genTestTxs(5, addr)
+	txSrv := newTestPayServer()
+	txSrv.txResps = txs
+	defer txSrv.Close()
+
+	secret := "test-secret"
+	lock := sync.Mutex{}
+	fail := true
+	received := make([]schema.WebhookEvent, 0)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		err := utils.VerifyWebhook(secret, r.Header.Get(schema.WebhookTimestampHeader), body,
+			r.Header.Get(schema.WebhookSignatureHeader), time.Minute)
+		if !assert.NoError(t, err) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		evt := schema.WebhookEvent{}
+		assert.NoError(t, json.Unmarshal(body, &evt))
+		received = append(received, evt)
+	}))
+	defer receiver.Close()
+
+	store := NewMemoryDeadLetterStore()
+	d := NewWebhookDispatcher(NewClient(txSrv.URL), WebhookConfig{
+		Endpoints: []WebhookEndpoint{{
+			URL:    receiver.URL,
+			Secret: secret,
+			Filter: schema.TxOpts{Address: addr, MinAmount: big.NewInt(3)},
+		}},
+		MaxRetries: 2,
+		Backoff:    time.Millisecond,
+	}, store)
+
+	defer d.Stop()
+
+	// receiver fail, go to dead letter
+	d.Dispatch(txs[3])
+	var dls []schema.DeadLetter
+	assert.Eventually(t, func() bool {
+		dls, _ = store.DeadLetters()
+		return len(dls) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, int64(4), dls[0].RawId)
+	assert.Equal(t, 3, dls[0].Attempts)
+	createdAt := dls[0].CreatedAt
+
+	// failed replay update the dead letter
+	assert.Error(t, d.Replay(4))
+	dls, _ = store.DeadLetters()
+	assert.Equal(t, 1, len(dls))
+	assert.Equal(t, 6, dls[0].Attempts)
+	assert.Equal(t, "webhook response status: 500", dls[0].Error)
+	assert.Equal(t, createdAt, dls[0].CreatedAt)
+
+	// replay dead letter
+	lock.Lock()
+	fail = false
+	lock.Unlock()
+	assert.NoError(t, d.Replay(4))
+	dls, _ = store.DeadLetters()
+	assert.Equal(t, 0, len(dls))
+
+	// filtered by MinAmount
+	d.Dispatch(txs[0])
+	d.Dispatch(txs[4])
+	assert.Eventually(t, func() bool {
+		lock.Lock()
+		defer lock.Unlock()
+		return len(received) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, int64(4), received[0].RawId)
+	assert.Equal(t, txs[4].EverHash, received[1].EverHash)
+}
+
+func TestWebhookDispatcher_Queue(t *testing.T) {
+	txs := genTestTxs(5, "0x3D7e9DFbc58952FdACEe2a5C69367C8478474D82")
+	block := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer slow.Close()
+	defer close(block)
+	fastCount := make(chan int64, 10)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		evt := schema.WebhookEvent{}
+		json.NewDecoder(r.Body).Decode(&evt)
+		fastCount <- evt.RawId
+	}))
+	defer fast.Close()
+
+	store := NewMemoryDeadLetterStore()
+	d := NewWebhookDispatcher(nil, WebhookConfig{
+		Endpoints: []WebhookEndpoint{{URL: slow.URL}, {URL: fast.URL}},
+		Timeout:   time.Minute,
+		QueueSize: 2,
+	}, store)
+
+	ch := make(chan schema.TxResponse)
+	done := make(chan struct{})
+	go func() {
+		d.Run(ch)
+		close(done)
+	}()
+	// slow endpoint does not delay the fast one
+	for _, tx := range txs {
+		ch <- tx
+		select {
+		case rawId := <-fastCount:
+			assert.Equal(t, tx.RawId, rawId)
+		case <-time.After(5 * time.Second):
+			t.Fatal("fast endpoint webhook timeout")
+		}
+	}
+	// Run return when txs closed
+	close(ch)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run not return after txs closed")
+	}
+
+	// 1 in flight and 2 queued by slow endpoint, others are dead letters
+	dls, err := store.DeadLetters()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(dls))
+	for i, dl := range dls {
+		assert.Equal(t, slow.URL, dl.URL)
+		assert.Equal(t, int64(i+4), dl.RawId)
+		assert.Equal(t, "webhook queue full", dl.Error)
+	}
+}
+
+func TestVerifyWebhook(t *testing.T) {
+	body := []byte(`{"rawId":1}`)
+	ts := time.Now().Unix()
+	sig := utils.SignWebhook("secret", ts, body)
+	assert.NoError(t, utils.VerifyWebhook("secret", strconv.FormatInt(ts, 10), body, sig, time.Minute))
+	assert.Equal(t, schema.ERR_INVALID_SIGNATURE, utils.VerifyWebhook("other", strconv.FormatInt(ts, 10), body, sig, time.Minute))
+
+	old := ts - 3600
+	oldSig := utils.SignWebhook("secret", old, body)
+	assert.Equal(t, schema.ERR_INVALID_SIGNATURE, utils.VerifyWebhook("secret", strconv.FormatInt(old, 10), body, oldSig, time.Minute))
+	assert.NoError(t, utils.VerifyWebhook("secret", strconv.FormatInt(old, 10), body, oldSig, 0))
+}
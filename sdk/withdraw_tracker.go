@@ -0,0 +1,223 @@
+package sdk
+
+import (
+	"sync"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/utils"
+)
+
+// TargetChainChecker check the withdraw tx on target chain
+// return schema.WithdrawStatusBroadcast(not confirmed yet), schema.WithdrawStatusSuccess or schema.WithdrawStatusFailed
+type TargetChainChecker interface {
+	CheckTx(targetChainType, txHash string) (status string, err error)
+}
+
+type WithdrawTrackOpts struct {
+	Interval time.Duration // poll interval, default 10s
+	Timeout  time.Duration // default 2h
+	// Checker option, without checker the tracker finished when withdraw tx broadcast
+	Checker TargetChainChecker
+	// Callback option, called on every status transition
+	Callback func(schema.WithdrawTxResponse)
+	// IsRefund option, check whether tx refunds the burn tx, tx is the mint of the same token to burn.From.
+	// everPay does not define the refund tx data, refund is not searched without IsRefund.
+	// with IsRefund, tracking goes on after failed until refunded or timeout
+	IsRefund func(burn, tx schema.TxResponse) bool
+}
+
+// WithdrawTracker follow a burn everTx until the withdraw tx succeed or failed on target chain, or refunded
+type WithdrawTracker struct {
+	client   *Client
+	everHash string
+	opts     WithdrawTrackOpts
+
+	lock         sync.RWMutex
+	result       schema.WithdrawTxResponse
+	refundCursor int64
+
+	ch       chan schema.WithdrawTxResponse
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+// TrackWithdraw track the burn everTx returned by SDK.Withdraw
+func (c *Client) TrackWithdraw(everHash string, opts WithdrawTrackOpts) *WithdrawTracker {
+	if opts.Interval <= 0 {
+		opts.Interval = 10 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Hour
+	}
+	w := &WithdrawTracker{
+		client:   c,
+		everHash: everHash,
+		opts:     opts,
+		result: schema.WithdrawTxResponse{
+			EverHash: everHash,
+			Status:   schema.WithdrawStatusPending,
+		},
+		// every status is sent once at most, chan never blocks
+		ch:   make(chan schema.WithdrawTxResponse, 8),
+		quit: make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Subscribe return status transitions, the chan is closed when tracking finished
+func (w *WithdrawTracker) Subscribe() <-chan schema.WithdrawTxResponse {
+	return w.ch
+}
+
+// Result return the latest status
+func (w *WithdrawTracker) Result() schema.WithdrawTxResponse {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.result
+}
+
+func (w *WithdrawTracker) Stop() {
+	w.quitOnce.Do(func() {
+		close(w.quit)
+	})
+}
+
+func (w *WithdrawTracker) run() {
+	defer close(w.ch)
+	deadline := time.NewTimer(w.opts.Timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		res, finished := w.poll()
+		w.update(res)
+		if finished {
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			// failed withdraw is not refunded in time, keep the failed status
+			res = w.Result()
+			if res.Status != schema.WithdrawStatusFailed {
+				res.Status = schema.WithdrawStatusTimeout
+				w.update(res)
+			}
+			return
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+func (w *WithdrawTracker) update(res schema.WithdrawTxResponse) {
+	w.lock.Lock()
+	changed := w.result.Status != res.Status
+	w.result = res
+	w.lock.Unlock()
+	if !changed {
+		return
+	}
+
+	if w.opts.Callback != nil {
+		w.opts.Callback(res)
+	}
+	w.ch <- res
+}
+
+// poll return current status and whether tracking finished
+func (w *WithdrawTracker) poll() (res schema.WithdrawTxResponse, finished bool) {
+	res = w.Result()
+	tx, err := w.client.TxByHash(w.everHash)
+	if err != nil || tx.Tx == nil {
+		log.Debug("withdraw tx not found", "everHash", w.everHash, "err", err)
+		return res, false
+	}
+	burn := *tx.Tx
+	res.Token = burn.Tag()
+	res.WithdrawFee = burn.Fee
+	if burn.Action != schema.TxActionBurn {
+		res.Status = schema.WithdrawStatusFailed
+		res.Error = "not burn tx"
+		return res, true
+	}
+
+	if res.Status == schema.WithdrawStatusPending || res.Status == schema.WithdrawStatusPackaged {
+		res.Status = schema.WithdrawStatusPackaged
+		if burn.Status == schema.TxStatusConfirmed {
+			res.Status = schema.WithdrawStatusConfirmed
+		}
+	}
+	if burn.TargetChainTxHash != "" && res.Status != schema.WithdrawStatusFailed {
+		res.WithdrawTx = burn.TargetChainTxHash
+		res.Status = schema.WithdrawStatusBroadcast
+		if w.opts.Checker == nil {
+			return res, true
+		}
+
+		targetChainType, err := utils.GetTargetChainTypeFromData(burn.Data, burn.Action, burn.ChainType)
+		if err != nil {
+			res.Error = err.Error()
+			return res, false
+		}
+		status, err := w.opts.Checker.CheckTx(targetChainType, burn.TargetChainTxHash)
+		if err != nil {
+			log.Warn("check target chain tx failed", "everHash", w.everHash, "err", err)
+			return res, false
+		}
+		switch status {
+		case schema.WithdrawStatusSuccess:
+			res.Status = status
+			return res, true
+		case schema.WithdrawStatusFailed:
+			res.Status = status
+			if w.opts.IsRefund == nil {
+				return res, true
+			}
+		}
+	}
+	if res.Status == schema.WithdrawStatusPackaged || w.opts.IsRefund == nil {
+		return res, false
+	}
+
+	// withdraw may be refunded without target chain tx
+	refundTx, err := w.findRefund(burn)
+	if err != nil {
+		log.Warn("find refund tx failed", "everHash", w.everHash, "err", err)
+		return res, false
+	}
+	if refundTx != "" {
+		res.RefundTx = refundTx
+		res.Status = schema.WithdrawStatusRefunded
+		return res, true
+	}
+	return res, false
+}
+
+// findRefund find the refund mint tx of burn after burn.RawId
+func (w *WithdrawTracker) findRefund(burn schema.TxResponse) (string, error) {
+	if w.refundCursor == 0 {
+		w.refundCursor = burn.RawId
+	}
+	txs, err := w.client.Txs(w.refundCursor, OrderByAsc, 100, schema.TxOpts{To: burn.From, Action: schema.TxActionMint})
+	if err != nil {
+		return "", err
+	}
+	if txs.NextCursor > 0 {
+		w.refundCursor = txs.NextCursor
+	}
+	for _, tx := range txs.Txs {
+		if tx.Action != schema.TxActionMint || tx.Tag() != burn.Tag() ||
+			utils.FormatAccId(tx.To) != utils.FormatAccId(burn.From) {
+			continue
+		}
+		if w.opts.IsRefund(burn, tx) {
+			return tx.EverHash, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,165 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/tidwall/gjson"
+)
+
+type testChecker struct {
+	status string
+}
+
+func (c testChecker) CheckTx(targetChainType, txHash string) (string, error) {
+	return c.status, nil
+}
+
+func testBurnTx() schema.TxResponse {
+	return schema.TxResponse{
+		RawId: 1, Action: schema.TxActionBurn, TokenSymbol: "USDT", ChainType: "ethereum",
+		TokenID: "0xdac17f958d2ee523a2206206994597c13d831ec7", From: testSignerAddr, To: testSignerAddr,
+		Amount: "100", Fee: "1", Data: `{"targetChainType":"ethereum"}`, Status: schema.TxStatusConfirmed,
+		EverHash: "0xb0",
+	}
+}
+
+func waitWithdraw(t *testing.T, w *WithdrawTracker) []string {
+	statuses := make([]string, 0)
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case res, ok := <-w.Subscribe():
+			if !ok {
+				return statuses
+			}
+			statuses = append(statuses, res.Status)
+		case <-timeout:
+			t.Fatal("withdraw tracker not finished")
+		}
+	}
+}
+
+func TestWithdrawTracker_Broadcast(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	burn := testBurnTx()
+	burn.Status = schema.TxStatusPackaged
+	srv.txResps = []schema.TxResponse{burn}
+
+	w := NewClient(srv.URL).TrackWithdraw(burn.EverHash, WithdrawTrackOpts{Interval: 10 * time.Millisecond})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		srv.update(func(p *testPayServer) {
+			p.txResps[0].Status = schema.TxStatusConfirmed
+			p.txResps[0].TargetChainTxHash = "0xeth"
+		})
+	}()
+	assert.Equal(t, []string{schema.WithdrawStatusPackaged, schema.WithdrawStatusBroadcast}, waitWithdraw(t, w))
+	res := w.Result()
+	assert.Equal(t, "0xeth", res.WithdrawTx)
+	assert.Equal(t, testTokenTag, res.Token)
+	assert.Equal(t, "1", res.WithdrawFee)
+}
+
+// testIsRefund refund mint tx of test server has the burn everHash in data
+func testIsRefund(burn, tx schema.TxResponse) bool {
+	return gjson.Get(tx.Data, "refund").String() == burn.EverHash
+}
+
+func TestWithdrawTracker_Checker(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	burn := testBurnTx()
+	burn.TargetChainTxHash = "0xeth"
+	srv.txResps = []schema.TxResponse{burn}
+
+	w := NewClient(srv.URL).TrackWithdraw(burn.EverHash, WithdrawTrackOpts{
+		Interval: 10 * time.Millisecond, Checker: testChecker{status: schema.WithdrawStatusSuccess}, IsRefund: testIsRefund,
+	})
+	assert.Equal(t, []string{schema.WithdrawStatusSuccess}, waitWithdraw(t, w))
+
+	// failed is terminal without IsRefund
+	w = NewClient(srv.URL).TrackWithdraw(burn.EverHash, WithdrawTrackOpts{
+		Interval: 10 * time.Millisecond, Checker: testChecker{status: schema.WithdrawStatusFailed},
+	})
+	assert.Equal(t, []string{schema.WithdrawStatusFailed}, waitWithdraw(t, w))
+	assert.Equal(t, "", w.Result().RefundTx)
+
+	// failed and not refunded before timeout
+	w = NewClient(srv.URL).TrackWithdraw(burn.EverHash, WithdrawTrackOpts{
+		Interval: 10 * time.Millisecond, Timeout: 100 * time.Millisecond,
+		Checker: testChecker{status: schema.WithdrawStatusFailed}, IsRefund: testIsRefund,
+	})
+	assert.Equal(t, []string{schema.WithdrawStatusFailed}, waitWithdraw(t, w))
+	assert.Equal(t, schema.WithdrawStatusFailed, w.Result().Status)
+
+	// failed then refunded
+	w = NewClient(srv.URL).TrackWithdraw(burn.EverHash, WithdrawTrackOpts{
+		Interval: 10 * time.Millisecond, Checker: testChecker{status: schema.WithdrawStatusFailed}, IsRefund: testIsRefund,
+	})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		refund := testBurnTx()
+		refund.RawId, refund.Action, refund.EverHash, refund.Data = 2, schema.TxActionMint, "0xr0", `{"refund":"0xb0"}`
+		srv.update(func(p *testPayServer) { p.txResps = append(p.txResps, refund) })
+	}()
+	assert.Equal(t, []string{schema.WithdrawStatusFailed, schema.WithdrawStatusRefunded}, waitWithdraw(t, w))
+	res := w.Result()
+	assert.Equal(t, "0xr0", res.RefundTx)
+	assert.Equal(t, "0xeth", res.WithdrawTx)
+
+	// target chain tx not confirmed yet, refunded
+	w = NewClient(srv.URL).TrackWithdraw(burn.EverHash, WithdrawTrackOpts{
+		Interval: 10 * time.Millisecond, Checker: testChecker{status: schema.WithdrawStatusBroadcast}, IsRefund: testIsRefund,
+	})
+	assert.Equal(t, []string{schema.WithdrawStatusRefunded}, waitWithdraw(t, w))
+	assert.Equal(t, "0xr0", w.Result().RefundTx)
+}
+
+func TestWithdrawTracker_Refund(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+	burn := testBurnTx()
+	// only the mint of same token to burn.From is checked by IsRefund
+	memo := testBurnTx()
+	memo.RawId, memo.Action, memo.EverHash, memo.Data = 2, schema.TxActionTransfer, "0x02", `{"refund":"0xb0"}`
+	mint := testBurnTx()
+	mint.RawId, mint.Action, mint.EverHash, mint.Data = 3, schema.TxActionMint, "0x03", `{"memo":"refund of 0xb0"}`
+	otherToken := testBurnTx()
+	otherToken.RawId, otherToken.Action, otherToken.EverHash, otherToken.TokenSymbol = 4, schema.TxActionMint, "0x04", "ETH"
+	otherToken.Data = `{"refund":"0xb0"}`
+	srv.txResps = []schema.TxResponse{burn, memo, mint, otherToken}
+
+	// refund is not searched without IsRefund
+	w := NewClient(srv.URL).TrackWithdraw(burn.EverHash, WithdrawTrackOpts{Interval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond})
+	assert.Equal(t, []string{schema.WithdrawStatusConfirmed, schema.WithdrawStatusTimeout}, waitWithdraw(t, w))
+
+	w = NewClient(srv.URL).TrackWithdraw(burn.EverHash, WithdrawTrackOpts{Interval: 10 * time.Millisecond, IsRefund: testIsRefund})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		refund := testBurnTx()
+		refund.RawId, refund.Action, refund.EverHash = 5, schema.TxActionMint, "0x05"
+		refund.Data = `{"refund":"0xb0"}`
+		srv.update(func(p *testPayServer) { p.txResps = append(p.txResps, refund) })
+	}()
+	assert.Equal(t, []string{schema.WithdrawStatusConfirmed, schema.WithdrawStatusRefunded}, waitWithdraw(t, w))
+	assert.Equal(t, "0x05", w.Result().RefundTx)
+}
+
+func TestWithdrawTracker_Timeout(t *testing.T) {
+	srv := newTestPayServer()
+	defer srv.Close()
+
+	w := NewClient(srv.URL).TrackWithdraw("0xb0", WithdrawTrackOpts{Interval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond})
+	assert.Equal(t, []string{schema.WithdrawStatusTimeout}, waitWithdraw(t, w))
+
+	burn := testBurnTx()
+	burn.Action = schema.TxActionTransfer
+	srv.txResps = []schema.TxResponse{burn}
+	w = NewClient(srv.URL).TrackWithdraw(burn.EverHash, WithdrawTrackOpts{Interval: 10 * time.Millisecond})
+	assert.Equal(t, []string{schema.WithdrawStatusFailed}, waitWithdraw(t, w))
+	assert.Equal(t, "not burn tx", w.Result().Error)
+}
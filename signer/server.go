@@ -0,0 +1,252 @@
+package signer
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/everVision/everpay-kits/common"
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/sdk"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/gin-gonic/gin"
+)
+
+var log = common.NewLog("signer")
+
+// Server remote signer daemon, sign the txs of its own key after policy check
+type Server struct {
+	signer     interface{} // *goar.Signer, *goether.Signer or *sdk.EverIdSigner
+	signerType string
+	address    string
+	token      string
+
+	policy *sdk.PolicyEngine
+	audit  sdk.AuditSink
+	engine *gin.Engine
+
+	signLock sync.Mutex // held from policy check to record, concurrent requests can not exceed the limits
+}
+
+// New token: auth token of clients
+func New(signer interface{}, token string) (*Server, error) {
+	info, err := signerInfo(signer)
+	if err != nil {
+		return nil, err
+	}
+	if token == "" {
+		return nil, schema.ERR_INVALID_AUTH_TOKEN
+	}
+	srv := &Server{
+		signer:     signer,
+		signerType: info.SignerType,
+		address:    utils.FormatAccId(info.Address),
+		token:      token,
+		engine:     gin.New(),
+	}
+	srv.engine.Use(gin.Recovery(), srv.auth())
+	srv.engine.GET("/signer", srv.getSigner)
+	srv.engine.POST("/sign/tx", srv.signTx)
+	srv.engine.POST("/sign/bundle", srv.signBundle)
+	return srv, nil
+}
+
+func (s *Server) SetPolicy(p *sdk.PolicyEngine) {
+	s.policy = p
+}
+
+func (s *Server) SetAuditSink(sink sdk.AuditSink) {
+	s.audit = sink
+}
+
+func (s *Server) Handler() http.Handler {
+	return s.engine
+}
+
+func (s *Server) Run(addr string) error {
+	log.Info("signer listening", "addr", addr, "address", s.address)
+	return s.engine.Run(addr)
+}
+
+func (s *Server) auth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			errorResponse(c, http.StatusUnauthorized, schema.ERR_INVALID_AUTH_TOKEN)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func (s *Server) getSigner(c *gin.Context) {
+	c.JSON(http.StatusOK, schema.RemoteSignerInfo{Address: s.address, SignerType: s.signerType})
+}
+
+func (s *Server) signTx(c *gin.Context) {
+	req := schema.RemoteSignTxReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	tx := req.Tx
+	tx.Sig = ""
+	if utils.FormatAccId(tx.From) != s.address {
+		errorResponse(c, http.StatusBadRequest, schema.ERR_SIGNER_INCORRECT)
+		return
+	}
+	tokenTag, err := sdk.NormalizeTag(tx.Tag())
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	s.signLock.Lock()
+	defer s.signLock.Unlock()
+	if err := s.checkPolicy(tokenTag, tx.Action, tx.To, tx.Amount, tx.Fee, tx.Nonce); err != nil {
+		errorResponse(c, http.StatusForbidden, err)
+		return
+	}
+
+	preimage := tx.String()
+	sig, err := sdk.SignMsg(s.signer, preimage)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+	err = s.appendAudit(schema.AuditRecord{
+		Time:     time.Now().UnixMilli(),
+		Event:    schema.AuditEventSign,
+		Signer:   s.address,
+		TokenTag: tokenTag,
+		Action:   tx.Action,
+		To:       tx.To,
+		Amount:   tx.Amount,
+		Fee:      tx.Fee,
+		Nonce:    tx.Nonce,
+		Preimage: preimage,
+		EverHash: tx.HexHash(),
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+	if s.policy != nil {
+		s.policy.Record(tokenTag, tx.Amount, tx.Fee)
+	}
+	log.Info("signed tx", "everHash", tx.HexHash(), "action", tx.Action, "tokenTag", tokenTag, "to", tx.To, "amount", tx.Amount)
+	c.JSON(http.StatusOK, schema.RemoteSignResp{Sig: sig, Hash: tx.HexHash()})
+}
+
+func (s *Server) signBundle(c *gin.Context) {
+	req := schema.RemoteSignBundleReq{}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+	bundle := req.Bundle
+	// items of the same token spent by signer are checked as one spend
+	tags, spends := sdk.BundleSpends(bundle, s.address)
+
+	s.signLock.Lock()
+	defer s.signLock.Unlock()
+	if s.policy != nil {
+		for _, tag := range tags {
+			record, err := s.policy.EvaluateBundle(s.address, tag, bundle.Salt, spends[tag])
+			if err == nil {
+				err = s.denyToken(&record)
+			}
+			if auditErr := s.appendAudit(record); auditErr != nil {
+				log.Error("append audit record failed", "event", record.Event, "err", auditErr)
+			}
+			if err != nil {
+				errorResponse(c, http.StatusForbidden, err)
+				return
+			}
+		}
+	}
+
+	preimage := bundle.String()
+	sig, err := sdk.SignMsg(s.signer, preimage)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+	err = s.appendAudit(schema.AuditRecord{
+		Time:     time.Now().UnixMilli(),
+		Event:    schema.AuditEventSign,
+		Signer:   s.address,
+		TokenTag: strings.Join(tags, ","),
+		Action:   schema.TxActionBundle,
+		Nonce:    bundle.Salt,
+		Preimage: preimage,
+		EverHash: bundle.HashHex(),
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+	if s.policy != nil {
+		for _, tag := range tags {
+			s.policy.RecordBundle(tag, spends[tag])
+		}
+	}
+	log.Info("signed bundle", "hash", bundle.HashHex(), "items", len(bundle.Items))
+	c.JSON(http.StatusOK, schema.RemoteSignResp{Sig: sig, Hash: bundle.HashHex()})
+}
+
+func (s *Server) checkPolicy(tokenTag, action, to, amount, fee, nonce string) error {
+	if s.policy == nil {
+		return nil
+	}
+	record, err := s.policy.Evaluate(s.address, tokenTag, action, to, amount, fee, nonce)
+	if err == nil {
+		err = s.denyToken(&record)
+	}
+	if auditErr := s.appendAudit(record); auditErr != nil {
+		log.Error("append audit record failed", "event", record.Event, "err", auditErr)
+	}
+	return err
+}
+
+// denyToken deny the token not in policy, so every tx signed with policy is limited
+func (s *Server) denyToken(record *schema.AuditRecord) error {
+	if s.policy.HasToken(record.TokenTag) {
+		return nil
+	}
+	v := schema.PolicyViolation{Rule: schema.PolicyRuleToken, Action: record.Action, TokenTag: record.TokenTag, Msg: "token not in policy"}
+	record.Decision, record.Rule, record.Reason = schema.PolicyDecisionDeny, v.Rule, v.Msg
+	log.Warn("tx denied by policy", "tokenTag", record.TokenTag, "action", record.Action, "err", v)
+	return v
+}
+
+func (s *Server) appendAudit(record schema.AuditRecord) error {
+	if s.audit == nil {
+		return nil
+	}
+	return s.audit.Append(record)
+}
+
+func signerInfo(signer interface{}) (schema.RemoteSignerInfo, error) {
+	if _, ok := signer.(*sdk.RemoteSigner); ok {
+		return schema.RemoteSignerInfo{}, errors.New("not support this signer")
+	}
+	signerType, address, err := sdk.SignerInfo(signer)
+	if err != nil {
+		return schema.RemoteSignerInfo{}, err
+	}
+	return schema.RemoteSignerInfo{Address: address, SignerType: signerType}, nil
+}
+
+func errorResponse(c *gin.Context, status int, err error) {
+	resp := schema.RespPolicyErr{Err: err.Error()}
+	v := schema.PolicyViolation{}
+	if errors.As(err, &v) {
+		resp.Violation = &v
+	}
+	c.JSON(status, resp)
+}
@@ -0,0 +1,254 @@
+package signer
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/everFinance/goether"
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/everVision/everpay-kits/sdk"
+	"github.com/everVision/everpay-kits/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTag = "ethereum-usdt-0xdac17f958d2ee523a2206206994597c13d831ec7"
+
+func newTestSigner(t *testing.T) (*Server, *httptest.Server) {
+	gin.SetMode(gin.TestMode)
+	key, err := goether.NewSigner("ad1dcf8f1c449e7af21a7b8341eba5f053055819fff9948f1251ea94a0184cae")
+	assert.NoError(t, err)
+	srv, err := New(key, "token-01")
+	assert.NoError(t, err)
+	return srv, httptest.NewServer(srv.Handler())
+}
+
+func testTx(from string) schema.Transaction {
+	return schema.Transaction{
+		TokenSymbol: "USDT",
+		Action:      schema.TxActionTransfer,
+		From:        from,
+		To:          "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223",
+		Amount:      "100",
+		Fee:         "0",
+		Nonce:       "1672531200000",
+		TokenID:     "0xdac17f958d2ee523a2206206994597c13d831ec7",
+		ChainType:   "ethereum",
+		ChainID:     "1",
+		Version:     schema.TxVersionV1,
+	}
+}
+
+func TestRemoteSigner(t *testing.T) {
+	_, daemon := newTestSigner(t)
+	defer daemon.Close()
+
+	_, err := sdk.NewRemoteSigner(daemon.URL, "token-02")
+	assert.EqualError(t, err, schema.ERR_INVALID_AUTH_TOKEN.Error())
+
+	rs, err := sdk.NewRemoteSigner(daemon.URL, "token-01")
+	assert.NoError(t, err)
+	assert.Equal(t, sdk.EccSignerType, rs.SignerType)
+
+	tx := testTx(rs.Address)
+	sig, err := rs.SignTx(tx)
+	assert.NoError(t, err)
+	_, err = utils.Verify(schema.AccountTypeEVM, rs.Address, sig, tx.Hash(), 1)
+	assert.NoError(t, err)
+
+	// only sign the txs of its own key
+	_, err = rs.SignTx(testTx("0x61EbF673c200646236B2c53465bcA0699455d5FA"))
+	assert.EqualError(t, err, schema.ERR_SIGNER_INCORRECT.Error())
+
+	bundle := sdk.GenBundle([]schema.BundleItem{{Tag: testTag, ChainID: "1", From: rs.Address, To: tx.To, Amount: "1"}}, time.Now().Unix()+100)
+	sig, err = rs.SignBundle(bundle)
+	assert.NoError(t, err)
+	_, err = utils.Verify(schema.AccountTypeEVM, rs.Address, sig, bundle.Hash(), 1)
+	assert.NoError(t, err)
+}
+
+func TestRemoteSigner_Policy(t *testing.T) {
+	srv, daemon := newTestSigner(t)
+	defer daemon.Close()
+	p, err := sdk.NewPolicyEngine(schema.Policy{
+		Tokens: map[string]schema.TokenPolicy{testTag: {MaxAmount: "50"}},
+	})
+	assert.NoError(t, err)
+	srv.SetPolicy(p)
+	sink := &testAuditSink{}
+	srv.SetAuditSink(sink)
+
+	rs, err := sdk.NewRemoteSigner(daemon.URL, "token-01")
+	assert.NoError(t, err)
+	_, err = rs.SignTx(testTx(rs.Address))
+	v := schema.PolicyViolation{}
+	assert.True(t, errors.As(err, &v))
+	assert.Equal(t, schema.PolicyRuleMaxAmount, v.Rule)
+
+	tx := testTx(rs.Address)
+	tx.Amount = "50"
+	_, err = rs.SignTx(tx)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, len(sink.records))
+	assert.Equal(t, schema.PolicyDecisionDeny, sink.records[0].Decision)
+	assert.Equal(t, schema.AuditEventSign, sink.records[2].Event)
+	assert.Equal(t, tx.String(), sink.records[2].Preimage)
+
+	// token id in upper case is the same token
+	tx.TokenID, tx.Nonce = strings.ToUpper(tx.TokenID), "1672531200001"
+	_, err = rs.SignTx(tx)
+	assert.NoError(t, err)
+	assert.Equal(t, testTag, sink.records[len(sink.records)-1].TokenTag)
+
+	// token not in policy is not limited, deny it
+	other := testTx(rs.Address)
+	other.TokenSymbol, other.TokenID, other.Amount = "USDC", "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", "1"
+	_, err = rs.SignTx(other)
+	assert.True(t, errors.As(err, &v))
+	assert.Equal(t, schema.PolicyRuleToken, v.Rule)
+	last := sink.records[len(sink.records)-1]
+	assert.Equal(t, schema.PolicyDecisionDeny, last.Decision)
+	assert.Equal(t, schema.PolicyRuleToken, last.Rule)
+	bundle := sdk.GenBundle([]schema.BundleItem{{
+		Tag: "ethereum-usdc-0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", ChainID: "1", From: rs.Address, To: tx.To, Amount: "1",
+	}}, time.Now().Unix()+100)
+	_, err = rs.SignBundle(bundle)
+	assert.True(t, errors.As(err, &v))
+	assert.Equal(t, schema.PolicyRuleToken, v.Rule)
+
+	// invalid tag
+	other.TokenID = ""
+	_, err = rs.SignTx(other)
+	assert.EqualError(t, err, schema.ERR_INVALID_TAG.Error())
+}
+
+func TestRemoteSigner_PolicyConcurrent(t *testing.T) {
+	srv, daemon := newTestSigner(t)
+	defer daemon.Close()
+	p, err := sdk.NewPolicyEngine(schema.Policy{
+		Tokens: map[string]schema.TokenPolicy{testTag: {DailyLimit: "100"}},
+	})
+	assert.NoError(t, err)
+	srv.SetPolicy(p)
+	// slow sink widen the gap between policy check and record
+	srv.SetAuditSink(&testAuditSink{delay: 10 * time.Millisecond})
+	rs, err := sdk.NewRemoteSigner(daemon.URL, "token-01")
+	assert.NoError(t, err)
+
+	var (
+		wg     sync.WaitGroup
+		lock   sync.Mutex
+		signed int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tx := testTx(strings.ToLower(rs.Address))
+			tx.Amount = "50"
+			if _, err := rs.SignTx(tx); err == nil {
+				lock.Lock()
+				signed++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, signed)
+}
+
+func TestRemoteSigner_PolicyBundle(t *testing.T) {
+	srv, daemon := newTestSigner(t)
+	defer daemon.Close()
+	p, err := sdk.NewPolicyEngine(schema.Policy{
+		Tokens: map[string]schema.TokenPolicy{testTag: {DailyLimit: "100"}},
+	})
+	assert.NoError(t, err)
+	srv.SetPolicy(p)
+	sink := &testAuditSink{}
+	srv.SetAuditSink(sink)
+	rs, err := sdk.NewRemoteSigner(daemon.URL, "token-01")
+	assert.NoError(t, err)
+
+	to := "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223"
+	items := []schema.BundleItem{
+		{Tag: testTag, ChainID: "1", From: strings.ToLower(rs.Address), To: to, Amount: "60"},
+		{Tag: testTag, ChainID: "1", From: rs.Address, To: to, Amount: "60"},
+	}
+	_, err = rs.SignBundle(sdk.GenBundle(items, time.Now().Unix()+100))
+	v := schema.PolicyViolation{}
+	assert.True(t, errors.As(err, &v))
+	assert.Equal(t, schema.PolicyRuleDailyLimit, v.Rule)
+
+	bundle := sdk.GenBundle(items[:1], time.Now().Unix()+100)
+	_, err = rs.SignBundle(bundle)
+	assert.NoError(t, err)
+	last := sink.records[len(sink.records)-1]
+	assert.Equal(t, schema.AuditEventSign, last.Event)
+	assert.Equal(t, testTag, last.TokenTag)
+	assert.Equal(t, bundle.HashHex(), last.EverHash)
+
+	_, err = rs.SignBundle(sdk.GenBundle(items[1:], time.Now().Unix()+100))
+	assert.True(t, errors.As(err, &v))
+	assert.Equal(t, schema.PolicyRuleDailyLimit, v.Rule)
+}
+
+func TestSDK_RemoteSigner(t *testing.T) {
+	_, daemon := newTestSigner(t)
+	defer daemon.Close()
+	submitted := make([]schema.Transaction, 0)
+	paySrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info":
+			json.NewEncoder(w).Encode(schema.Info{TokenList: []schema.TokenInfo{{
+				Tag: testTag, Symbol: "USDT", ChainType: "ethereum", ChainID: "1", TransferFee: "0",
+				ID: "0xdac17f958d2ee523a2206206994597c13d831ec7",
+			}}})
+		case "/tx":
+			tx := schema.Transaction{}
+			json.NewDecoder(r.Body).Decode(&tx)
+			submitted = append(submitted, tx)
+			w.Write([]byte(`{"status":"ok"}`))
+		}
+	}))
+	defer paySrv.Close()
+
+	rs, err := sdk.NewRemoteSigner(daemon.URL, "token-01")
+	assert.NoError(t, err)
+	s, err := sdk.New(rs, paySrv.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, rs.Address, s.AccId)
+
+	_, err = s.Transfer(testTag, big.NewInt(10), "0x4002ED1a1410aF1b4930cF6c479ae373dEbD6223", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(submitted))
+	_, err = utils.Verify(schema.AccountTypeEVM, s.AccId, submitted[0].Sig, submitted[0].Hash(), 1)
+	assert.NoError(t, err)
+
+	_, err = s.Sign("msg")
+	assert.Equal(t, schema.ERR_SIGN_MSG_NOT_SUPPORT, err)
+}
+
+type testAuditSink struct {
+	lock    sync.Mutex
+	records []schema.AuditRecord
+	delay   time.Duration // of sign event
+}
+
+func (s *testAuditSink) Append(record schema.AuditRecord) error {
+	if record.Event == schema.AuditEventSign {
+		time.Sleep(s.delay)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.records = append(s.records, record)
+	return nil
+}
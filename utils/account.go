@@ -11,6 +11,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -58,6 +59,31 @@ func Verify(accType, accID string, sig string, hash []byte, chainID int) (public
 	return public, nil
 }
 
+// VerifyMsg verify the sig of msg signed by sdk signer of accID,
+// ar account signed sha256(msg), others signed TextHash(msg)
+func VerifyMsg(accID, msg, sig string, chainID int) error {
+	accType, accID, err := IDCheck(accID)
+	if err != nil {
+		return err
+	}
+	hash := accounts.TextHash([]byte(msg))
+	if accType == schema.AccountTypeAR {
+		h := sha256.Sum256([]byte(msg))
+		hash = h[:]
+	}
+	_, err = Verify(accType, accID, sig, hash, chainID)
+	return err
+}
+
+// FormatAccId return the accID of IDCheck, checksum address for EVM and the original id for AR and eid,
+// invalid id is returned as it is
+func FormatAccId(id string) string {
+	if _, accID, err := IDCheck(id); err == nil {
+		return accID
+	}
+	return id
+}
+
 func IDCheck(id string) (accountType, accID string, err error) {
 	if common.IsHexAddress(id) {
 		return schema.AccountTypeEVM, common.HexToAddress(id).String(), nil
@@ -118,6 +144,32 @@ func verifyEverIdSig(accID, txSig string, hash []byte, chainID int) ([]byte, err
 	}
 }
 
+// CheckPublic check the base64 encoded public of eid account by publicType
+func CheckPublic(publicType, public string) error {
+	publicBy, err := utils.Base64Decode(public)
+	if err != nil || len(publicBy) == 0 {
+		return schema.ERR_INVALID_PUBLIC
+	}
+	switch publicType {
+	case schema.EVMPublicType:
+		if _, err = crypto.UnmarshalPubkey(publicBy); err != nil {
+			return schema.ERR_INVALID_PUBLIC
+		}
+	case schema.ArPublicType:
+		if _, err = utils.OwnerToPubKey(public); err != nil {
+			return schema.ERR_INVALID_PUBLIC
+		}
+	case schema.FIDOPublicType:
+		cred := webauthn.Credential{}
+		if err = json.Unmarshal(publicBy, &cred); err != nil || len(cred.PublicKey) == 0 {
+			return schema.ERR_INVALID_PUBLIC
+		}
+	default:
+		return schema.ERR_INVALID_PUBLIC_TYPE
+	}
+	return nil
+}
+
 func DecodeEverIdSig(txSig string) (publicType, everSig string, public []byte, err error) {
 	// txSig = sig + "," + public + "," + publicType
 	// eccSig = eccSig,base64(publicBy),"ECDSA"
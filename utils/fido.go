@@ -3,8 +3,11 @@ package utils
 import (
 	"crypto/sha256"
 	"fmt"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/everFinance/goar/utils"
@@ -21,25 +24,91 @@ func GenUserId(eid string, chainID int) string {
 	return userId
 }
 
+var (
+	rpLock sync.RWMutex
+	// key: sha256(rpId), val: rpId
+	rpIdHashes = map[string]string{}
+	// key: rpId, val: allowed origins
+	rpOrigins = map[string][]string{}
+)
+
+func init() {
+	defaultOrigins := []string{schema.EverpayOrg, schema.EverpayDevOrg, schema.BetaDevEverpayOrg, schema.BetaEverpayOrg,
+		schema.LocalhostOrg}
+	for _, rpId := range []string{schema.LocalhostRpId, schema.EverpayRpId} {
+		if err := RegisterRpId(rpId, defaultOrigins...); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterRpId register a WebAuthn relying party and its allowed origins
+// rpId is a domain like "everpay.io", origin is like "https://app.everpay.io"
+// register an exist rpId will append the new origins
+func RegisterRpId(rpId string, origins ...string) error {
+	if rpId == "" || strings.ContainsAny(rpId, ":/ ") {
+		return schema.ERR_INVALID_RP_ID
+	}
+	if len(origins) == 0 {
+		return schema.ERR_INVALID_RP_ORIGIN
+	}
+	for _, org := range origins {
+		u, err := url.Parse(org)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return schema.ERR_INVALID_RP_ORIGIN
+		}
+	}
+
+	rpLock.Lock()
+	defer rpLock.Unlock()
+
+	hash := sha256.Sum256([]byte(rpId))
+	rpIdHashes[string(hash[:])] = rpId
+	for _, org := range origins {
+		if !schema.ContainsStr(rpOrigins[rpId], org, false) {
+			rpOrigins[rpId] = append(rpOrigins[rpId], org)
+		}
+	}
+	return nil
+}
+
+// RpIds return all registered rpIds
+func RpIds() []string {
+	rpLock.RLock()
+	defer rpLock.RUnlock()
+
+	ids := make([]string, 0, len(rpOrigins))
+	for rpId := range rpOrigins {
+		ids = append(ids, rpId)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// RpOrigins return the allowed origins of rpId
+func RpOrigins(rpId string) []string {
+	rpLock.RLock()
+	defer rpLock.RUnlock()
+
+	return append([]string{}, rpOrigins[rpId]...)
+}
+
 func GetWebAuthn(rpIdHash []byte) (*webauthn.WebAuthn, error) {
-	localhostRpIdHash := sha256.Sum256([]byte(schema.LocalhostRpId))
-	everpayioRpIdHash := sha256.Sum256([]byte(schema.EverpayRpId))
-	switch string(rpIdHash) {
-	case string(localhostRpIdHash[:]):
-		return webAuthn(schema.LocalhostRpId)
-	case string(everpayioRpIdHash[:]):
-		return webAuthn(schema.EverpayRpId)
-	default:
+	rpLock.RLock()
+	rpId, ok := rpIdHashes[string(rpIdHash)]
+	origins := append([]string{}, rpOrigins[rpId]...)
+	rpLock.RUnlock()
+	if !ok {
 		return nil, schema.ERR_RP_ID_NOT_EXIST
 	}
+	return webAuthn(rpId, origins)
 }
 
-func webAuthn(rpId string) (*webauthn.WebAuthn, error) {
+func webAuthn(rpId string, origins []string) (*webauthn.WebAuthn, error) {
 	return webauthn.New(&webauthn.Config{
-		RPID:          rpId,
-		RPDisplayName: "everpay",
-		RPOrigins: []string{schema.EverpayOrg, schema.EverpayDevOrg, schema.BetaDevEverpayOrg, schema.BetaEverpayOrg,
-			schema.LocalhostOrg},
+		RPID:                  rpId,
+		RPDisplayName:         "everpay",
+		RPOrigins:             origins,
 		AttestationPreference: "",
 		AuthenticatorSelection: protocol.AuthenticatorSelection{
 			UserVerification: protocol.VerificationRequired,
@@ -72,14 +141,20 @@ func checkFidoEmail(txNonce, txData string, everId string, payOwner string) erro
 	if err != nil {
 		return err
 	}
-	timeRange := nonce/1000 - timestamp   // txNonce unit is ms
-	if timeRange < 0 || timeRange > 300 { // timeRange 5 min
+	timeRange := nonce/1000 - timestamp // txNonce unit is ms
+	if timeRange < 0 || timeRange > schema.MailVerifyExpiration {
 		log.Debug("acc register timeRange", "nonce", nonce, "timestamp", timestamp, "range", timeRange)
 		return schema.ERR_EMAIL_CODE_EXPIRED
 	}
 	emailCode := jsData.Get("mailVerify").Get("code").String()
 	codeSig := jsData.Get("mailVerify").Get("sig").String()
 
+	return VerifyMailVerifySig(timestamp, emailCode, codeSig, everId, payOwner)
+}
+
+// VerifyMailVerifySig verify the email code sig issued by everPay owner
+// msg = timestamp + code + everId
+func VerifyMailVerifySig(timestamp int64, emailCode, codeSig, everId, payOwner string) error {
 	msg := []byte(fmt.Sprintf("%d", timestamp) + emailCode + everId)
 	sig, pubKey, addr, err := DecodeArSig(codeSig)
 	if err != nil {
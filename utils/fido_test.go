@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterRpId(t *testing.T) {
+	assert.Contains(t, RpIds(), schema.EverpayRpId)
+	assert.Contains(t, RpOrigins(schema.EverpayRpId), schema.EverpayOrg)
+
+	assert.Equal(t, schema.ERR_INVALID_RP_ID, RegisterRpId("", "https://pay.example.com"))
+	assert.Equal(t, schema.ERR_INVALID_RP_ID, RegisterRpId("https://pay.example.com", "https://pay.example.com"))
+	assert.Equal(t, schema.ERR_INVALID_RP_ORIGIN, RegisterRpId("example.com"))
+	assert.Equal(t, schema.ERR_INVALID_RP_ORIGIN, RegisterRpId("example.com", ""))
+	assert.Equal(t, schema.ERR_INVALID_RP_ORIGIN, RegisterRpId("example.com", "pay.example.com"))
+	assert.NotContains(t, RpIds(), "example.com")
+
+	hash := sha256.Sum256([]byte("example.com"))
+	_, err := GetWebAuthn(hash[:])
+	assert.Equal(t, schema.ERR_RP_ID_NOT_EXIST, err)
+
+	assert.NoError(t, RegisterRpId("example.com", "https://pay.example.com"))
+	assert.NoError(t, RegisterRpId("example.com", "https://pay.example.com", "https://shop.example.com"))
+	assert.Equal(t, []string{"https://pay.example.com", "https://shop.example.com"}, RpOrigins("example.com"))
+	assert.Contains(t, RpIds(), "example.com")
+
+	w, err := GetWebAuthn(hash[:])
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", w.Config.RPID)
+	assert.Equal(t, []string{"https://pay.example.com", "https://shop.example.com"}, w.Config.RPOrigins)
+	// default rpIds are not changed
+	assert.NotContains(t, RpOrigins(schema.EverpayRpId), "https://pay.example.com")
+}
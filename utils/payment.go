@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/everVision/everpay-kits/schema"
+)
+
+// EncodePaymentRequest encode payment request to everpay URI
+func EncodePaymentRequest(req schema.PaymentRequest) (string, error) {
+	if err := CheckPaymentRequest(req); err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("tag", req.TokenTag)
+	query.Set("amount", req.Amount)
+	if req.Memo != "" {
+		query.Set("memo", req.Memo)
+	}
+	if req.Expiration > 0 {
+		query.Set("exp", strconv.FormatInt(req.Expiration, 10))
+	}
+	if req.Callback != "" {
+		query.Set("callback", req.Callback)
+	}
+	uri := url.URL{
+		Scheme:   schema.PaymentURIScheme,
+		Opaque:   url.PathEscape(req.Recipient),
+		RawQuery: query.Encode(),
+	}
+	return uri.String(), nil
+}
+
+// DecodePaymentRequest decode everpay URI to payment request
+func DecodePaymentRequest(uri string) (req schema.PaymentRequest, err error) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != schema.PaymentURIScheme || u.Opaque == "" {
+		err = schema.ERR_INVALID_PAYMENT_REQUEST
+		return
+	}
+	recipient, err := url.PathUnescape(u.Opaque)
+	if err != nil {
+		err = schema.ERR_INVALID_PAYMENT_REQUEST
+		return
+	}
+	query := u.Query()
+	req = schema.PaymentRequest{
+		Recipient: recipient,
+		TokenTag:  query.Get("tag"),
+		Amount:    query.Get("amount"),
+		Memo:      query.Get("memo"),
+		Callback:  query.Get("callback"),
+	}
+	if exp := query.Get("exp"); exp != "" {
+		if req.Expiration, err = strconv.ParseInt(exp, 10, 64); err != nil {
+			err = schema.ERR_INVALID_PAYMENT_REQUEST
+			return
+		}
+	}
+	err = CheckPaymentRequest(req)
+	return
+}
+
+// CheckPaymentRequest check recipient, token tag, amount and callback
+func CheckPaymentRequest(req schema.PaymentRequest) error {
+	if !IsEmailAddress(req.Recipient) {
+		if _, _, err := IDCheck(req.Recipient); err != nil {
+			return err
+		}
+	}
+	if _, _, _, err := schema.ParseTag(req.TokenTag); err != nil {
+		return err
+	}
+	// check amount format only, decimals is checked by payer
+	if !decimalAmountReg.MatchString(req.Amount) || strings.Trim(req.Amount, "0.") == "" {
+		return schema.ERR_INVALID_AMOUNT
+	}
+	if req.Callback != "" {
+		u, err := url.Parse(req.Callback)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return schema.ERR_INVALID_PAYMENT_REQUEST
+		}
+	}
+	return nil
+}
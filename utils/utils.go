@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"regexp"
+	"strings"
 
 	"github.com/everFinance/ethrpc"
 	arTypes "github.com/everFinance/goar/types"
@@ -14,6 +16,8 @@ import (
 
 var log = common.NewLog("utils")
 
+var decimalAmountReg = regexp.MustCompile(`^\d+(\.\d+)?$`)
+
 func AsTokenTx(t schema.Transaction) (tokenTx schema.TokenTransaction, err error) {
 	amount, ok := new(big.Int).SetString(t.Amount, 10)
 	if !ok {
@@ -83,6 +87,44 @@ func AsTokenTx(t schema.Transaction) (tokenTx schema.TokenTransaction, err error
 	return
 }
 
+// ConvertDecimals convert amount from fromDecimals to toDecimals, the remainder is truncated
+func ConvertDecimals(amount *big.Int, fromDecimals, toDecimals int) *big.Int {
+	res := new(big.Int).Set(amount)
+	if toDecimals > fromDecimals {
+		return res.Mul(res, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(toDecimals-fromDecimals)), nil))
+	}
+	if toDecimals < fromDecimals {
+		return res.Quo(res, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(fromDecimals-toDecimals)), nil))
+	}
+	return res
+}
+
+// ParseAmount parse decimal amount string to integer amount by decimals, e.g. ("1.5", 6) => 1500000
+func ParseAmount(amount string, decimals int) (*big.Int, error) {
+	if !decimalAmountReg.MatchString(amount) {
+		return nil, schema.ERR_INVALID_AMOUNT
+	}
+	rat, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		return nil, schema.ERR_INVALID_AMOUNT
+	}
+	rat.Mul(rat, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+	if !rat.IsInt() {
+		// more decimal places than token decimals
+		return nil, schema.ERR_INVALID_AMOUNT
+	}
+	return new(big.Int).Set(rat.Num()), nil
+}
+
+// FormatAmount format integer amount to decimal amount string by decimals, e.g. (1500000, 6) => "1.5"
+func FormatAmount(amount *big.Int, decimals int) string {
+	s := new(big.Rat).SetFrac(amount, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)).FloatString(decimals)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
 func GetTargetChainTypeFromData(txData, txAction, txChainType string) (string, error) {
 	/*
 		1. tns101 Token mint tx must have json txData
@@ -133,27 +175,38 @@ func GetEverToNativeChainType(everChainType string) (string, error) {
 }
 
 func GetMintTargetTxHash(everTxChainType, everTxData string, everTxHash string) (targetChainTxHash string, err error) {
-	targetChainType, err := GetTargetChainTypeFromData(everTxData, schema.TxActionMint, everTxChainType)
+	mintData, err := decodeMintData(everTxChainType, everTxData, everTxHash)
 	if err != nil {
 		return "", err
 	}
+	return mintData.TargetChainTxHash, nil
+}
+
+func decodeMintData(everTxChainType, everTxData string, everTxHash string) (mintData *schema.MintTxData, err error) {
+	targetChainType, err := GetTargetChainTypeFromData(everTxData, schema.TxActionMint, everTxChainType)
+	if err != nil {
+		return nil, err
+	}
+	mintData = &schema.MintTxData{TargetChainType: targetChainType}
 	switch targetChainType { // more chain.
 	case schema.OracleEthChainType, schema.OracleMoonChainType, schema.OracleCfxChainType, schema.OracleBscChainType, schema.OraclePlatonChainType:
 		ethTx := ethrpc.Transaction{}
 		if err := json.Unmarshal([]byte(everTxData), &ethTx); err != nil {
 			log.Error("tx data unmarshal failed", "data", everTxData, "err", err)
-			return "", err
+			return nil, err
 		}
-		return ethTx.Hash, nil
+		mintData.EthTx = &ethTx
+		mintData.TargetChainTxHash = ethTx.Hash
 	case schema.OracleArweaveChainType:
 		arTx := arTypes.Transaction{}
 		if err = json.Unmarshal([]byte(everTxData), &arTx); err != nil {
 			log.Error("tx data unmarshal failed, data", everTxData, "err", err)
 			return
 		}
-		return arTx.ID, nil
+		mintData.ArTx = &arTx
+		mintData.TargetChainTxHash = arTx.ID
 	case schema.OracleEverpayChainType:
-		return everTxHash, nil
+		mintData.TargetChainTxHash = everTxHash
 	case schema.OracleAosChainType:
 		// get data(items), first item
 		items := struct {
@@ -166,11 +219,57 @@ func GetMintTargetTxHash(everTxChainType, everTxData string, everTxHash string)
 		}
 		if items.MainItem.Id == "" {
 			log.Error("aos mintTx data incorrect")
-			return "", errors.New("incorrect txData")
+			return nil, errors.New("incorrect txData")
 		}
-		return items.MainItem.Id, nil
+		mintData.AosItem = &items.MainItem
+		mintData.TargetChainTxHash = items.MainItem.Id
 	default:
 		err = fmt.Errorf("not support this targetChainType: %s", targetChainType)
-		return
+		return nil, err
+	}
+	return mintData, nil
+}
+
+// DecodeTxData decode tx data by tx action, return:
+// mint: *schema.MintTxData
+// burn: *schema.BurnTxData
+// bundle: *schema.BundleData
+// addWhiteList, removeWhiteList: *schema.WhiteListTxData
+// addBlackList, removeBlackList: *schema.BlackListTxData
+// pauseWhiteList, pauseBlackList, pause: *schema.PauseTxData
+// register: *schema.RegisterData
+// set: *schema.SetPublicData
+// transfer and others: *schema.RawTxData
+func DecodeTxData(tx schema.TxResponse) (interface{}, error) {
+	var data interface{}
+	switch tx.Action {
+	case schema.TxActionMint:
+		return decodeMintData(tx.ChainType, tx.Data, tx.EverHash)
+	case schema.TxActionBurn:
+		targetChainType, err := GetTargetChainTypeFromData(tx.Data, tx.Action, tx.ChainType)
+		if err != nil {
+			return nil, schema.ERR_INVALID_TARGET_CHAIN_TYPE
+		}
+		return &schema.BurnTxData{TargetChainType: targetChainType}, nil
+	case schema.TxActionBundle:
+		data = &schema.BundleData{}
+	case schema.TxActionAddWhiteList, schema.TxActionRemoveWhiteList:
+		data = &schema.WhiteListTxData{}
+	case schema.TxActionAddBlackList, schema.TxActionRemoveBlackList:
+		data = &schema.BlackListTxData{}
+	case schema.TxActionPauseWhiteList, schema.TxActionPauseBlackList, schema.TxActionPause:
+		data = &schema.PauseTxData{}
+	case schema.TxActionRegister:
+		data = &schema.RegisterData{}
+	case schema.TxActionSet:
+		data = &schema.SetPublicData{}
+	default:
+		return &schema.RawTxData{Data: tx.Data}, nil
+	}
+
+	if err := json.Unmarshal([]byte(tx.Data), data); err != nil {
+		log.Error("tx data unmarshal failed", "action", tx.Action, "everHash", tx.EverHash, "err", err)
+		return nil, schema.ERR_NOT_JSON_DATA
 	}
+	return data, nil
 }
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/everFinance/ethrpc"
+	arTypes "github.com/everFinance/goar/types"
+	"github.com/everVision/everpay-kits/schema"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeTxData(t *testing.T) {
+	errAny := errors.New("any error")
+	everHash := "0x3b3b4caa8b9c1afbe3e683093815d07fe576a64a48b29c7c693922c76357cb7a"
+	ethHash := "0x8a9b2e6d3e0e1f5d7b6a1c4e8f3b2a1d0c9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a"
+	arId := "ylP7Gu7vmImDRMX3K4K1TqtVT18ku0jC9gKS-XuTau8"
+
+	tests := []struct {
+		name      string
+		action    string
+		chainType string
+		data      string
+		want      interface{}
+		wantErr   error // nil: no error, errAny: any error
+	}{
+		{"mint ethereum", schema.TxActionMint, schema.ChainTypeEth, `{"hash":"` + ethHash + `"}`,
+			&schema.MintTxData{TargetChainType: schema.OracleEthChainType, TargetChainTxHash: ethHash, EthTx: &ethrpc.Transaction{Hash: ethHash}}, nil},
+		{"mint bsc", schema.TxActionMint, schema.ChainTypeBsc, `{"hash":"` + ethHash + `"}`,
+			&schema.MintTxData{TargetChainType: schema.OracleBscChainType, TargetChainTxHash: ethHash, EthTx: &ethrpc.Transaction{Hash: ethHash}}, nil},
+		{"mint cross chain to ethereum", schema.TxActionMint, schema.ChainTypeCrossArEth, `{"targetChainType":"ethereum","hash":"` + ethHash + `"}`,
+			&schema.MintTxData{TargetChainType: schema.OracleEthChainType, TargetChainTxHash: ethHash, EthTx: &ethrpc.Transaction{Hash: ethHash}}, nil},
+		{"mint arweave", schema.TxActionMint, schema.ChainTypeArweave, `{"id":"` + arId + `"}`,
+			&schema.MintTxData{TargetChainType: schema.OracleArweaveChainType, TargetChainTxHash: arId, ArTx: &arTypes.Transaction{ID: arId}}, nil},
+		{"mint everpay", schema.TxActionMint, schema.ChainTypeEverpay, "",
+			&schema.MintTxData{TargetChainType: schema.OracleEverpayChainType, TargetChainTxHash: everHash}, nil},
+		{"mint aos", schema.TxActionMint, schema.ChainTypeAos, `{"mainItem":{"id":"` + arId + `"},"pushedItem":{"id":"other"}}`,
+			&schema.MintTxData{TargetChainType: schema.OracleAosChainType, TargetChainTxHash: arId, AosItem: &arTypes.BundleItem{Id: arId}}, nil},
+		{"mint aos without mainItem", schema.TxActionMint, schema.ChainTypeAos, `{"pushedItem":{"id":"other"}}`, nil, errAny},
+		{"mint not json", schema.TxActionMint, schema.ChainTypeEth, "0x1234", nil, errAny},
+		{"mint unknown chain", schema.TxActionMint, "solana", `{"hash":"` + ethHash + `"}`, nil, errAny},
+		{"mint unknown target chain", schema.TxActionMint, schema.ChainTypeEth, `{"targetChainType":"solana"}`, nil, errAny},
+
+		{"burn", schema.TxActionBurn, schema.ChainTypeCrossArEth, `{"targetChainType":"ethereum"}`,
+			&schema.BurnTxData{TargetChainType: schema.OracleEthChainType}, nil},
+		{"burn to native chain", schema.TxActionBurn, schema.ChainTypeCrossArEth, "",
+			&schema.BurnTxData{TargetChainType: schema.OracleArweaveChainType}, nil},
+		{"burn unknown chain", schema.TxActionBurn, "solana", "", nil, schema.ERR_INVALID_TARGET_CHAIN_TYPE},
+
+		{"bundle", schema.TxActionBundle, schema.ChainTypeEverpay, `{"bundle":{"items":[{"tag":"` + arId + `","amount":"1"}],"expiration":1700000000,"salt":"s","version":"v1","sigs":{"0x01":"0x02"}}}`,
+			&schema.BundleData{Bundle: schema.BundleWithSigs{
+				Bundle: schema.Bundle{Items: []schema.BundleItem{{Tag: arId, Amount: "1"}}, Expiration: 1700000000, Salt: "s", Version: "v1"},
+				Sigs:   map[string]string{"0x01": "0x02"},
+			}}, nil},
+		{"bundle not json", schema.TxActionBundle, schema.ChainTypeEverpay, "bundle", nil, schema.ERR_NOT_JSON_DATA},
+		{"addWhiteList", schema.TxActionAddWhiteList, schema.ChainTypeEth, `{"whiteList":["0x01","0x02"]}`,
+			&schema.WhiteListTxData{WhiteList: []string{"0x01", "0x02"}}, nil},
+		{"removeWhiteList", schema.TxActionRemoveWhiteList, schema.ChainTypeEth, `{"whiteList":["0x01"]}`,
+			&schema.WhiteListTxData{WhiteList: []string{"0x01"}}, nil},
+		{"whiteList not json", schema.TxActionAddWhiteList, schema.ChainTypeEth, `["0x01"]`, nil, schema.ERR_NOT_JSON_DATA},
+		{"addBlackList", schema.TxActionAddBlackList, schema.ChainTypeEth, `{"blackList":["0x01"]}`,
+			&schema.BlackListTxData{BlackList: []string{"0x01"}}, nil},
+		{"removeBlackList", schema.TxActionRemoveBlackList, schema.ChainTypeEth, `{"blackList":["0x01","0x02"]}`,
+			&schema.BlackListTxData{BlackList: []string{"0x01", "0x02"}}, nil},
+		{"blackList not json", schema.TxActionRemoveBlackList, schema.ChainTypeEth, "", nil, schema.ERR_NOT_JSON_DATA},
+		{"pauseWhiteList", schema.TxActionPauseWhiteList, schema.ChainTypeEth, `{"pause":true}`, &schema.PauseTxData{Pause: true}, nil},
+		{"pauseBlackList", schema.TxActionPauseBlackList, schema.ChainTypeEth, `{"pause":false}`, &schema.PauseTxData{Pause: false}, nil},
+		{"pause", schema.TxActionPause, schema.ChainTypeEth, `{"pause":true}`, &schema.PauseTxData{Pause: true}, nil},
+		{"pause not json", schema.TxActionPause, schema.ChainTypeEth, "true", nil, schema.ERR_NOT_JSON_DATA},
+		{"register", schema.TxActionRegister, schema.ChainTypeEverpay, `{"mailVerify":{"timestamp":1700000000,"code":"123456","sig":"sig"},"public":"pub","publicType":"ECDSA"}`,
+			&schema.RegisterData{MailVerify: schema.MailVerify{Timestamp: 1700000000, Code: "123456", Sig: "sig"}, Public: "pub", PublicType: schema.EVMPublicType}, nil},
+		{"register not json", schema.TxActionRegister, schema.ChainTypeEverpay, "{", nil, schema.ERR_NOT_JSON_DATA},
+		{"transfer", schema.TxActionTransfer, schema.ChainTypeEth, `{"memo":"hi"}`, &schema.RawTxData{Data: `{"memo":"hi"}`}, nil},
+		{"transfer not json", schema.TxActionTransfer, schema.ChainTypeEth, "hi", &schema.RawTxData{Data: "hi"}, nil},
+		{"set addPublic", schema.TxActionSet, schema.ChainTypeEverpay, `{"operate":"addPublic","publicType":"ECDSA","public":"pub"}`,
+			&schema.SetPublicData{Operate: schema.SetOperateAddPublic, PublicType: schema.EVMPublicType, Public: "pub"}, nil},
+		{"set removePublic", schema.TxActionSet, schema.ChainTypeEverpay, `{"operate":"removePublic","publicId":"1"}`,
+			&schema.SetPublicData{Operate: schema.SetOperateRemovePublic, PublicId: "1"}, nil},
+		{"set not json", schema.TxActionSet, schema.ChainTypeEverpay, "set", nil, schema.ERR_NOT_JSON_DATA},
+		{"transferOwner", schema.TxActionTransferOwner, schema.ChainTypeEth, `{"newOwner":"0x01"}`, &schema.RawTxData{Data: `{"newOwner":"0x01"}`}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := DecodeTxData(schema.TxResponse{Action: tt.action, ChainType: tt.chainType, Data: tt.data, EverHash: everHash})
+			switch tt.wantErr {
+			case nil:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, data)
+			case errAny:
+				assert.Error(t, err)
+				assert.Nil(t, data)
+			default:
+				assert.Equal(t, tt.wantErr, err)
+				assert.Nil(t, data)
+			}
+		})
+	}
+}
+
+func TestGetMintTargetTxHash(t *testing.T) {
+	hash, err := GetMintTargetTxHash(schema.ChainTypeArweave, `{"id":"ylP7Gu7vmImDRMX3K4K1TqtVT18ku0jC9gKS-XuTau8"}`, "0x01")
+	assert.NoError(t, err)
+	assert.Equal(t, "ylP7Gu7vmImDRMX3K4K1TqtVT18ku0jC9gKS-XuTau8", hash)
+
+	hash, err = GetMintTargetTxHash(schema.ChainTypeEverpay, "", "0x01")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x01", hash)
+
+	_, err = GetMintTargetTxHash(schema.ChainTypeArweave, "not json", "0x01")
+	assert.Error(t, err)
+}
+
+func TestConvertDecimals(t *testing.T) {
+	tests := []struct {
+		amount       string
+		fromDecimals int
+		toDecimals   int
+		want         string
+	}{
+		{"123456", 6, 6, "123456"},
+		{"123456", 6, 18, "123456000000000000"},
+		{"0", 6, 18, "0"},
+		{"123456000000000000", 18, 6, "123456"},
+		// remainder is truncated
+		{"123456789012345678", 18, 6, "123456"},
+		{"999999999999", 18, 6, "0"},
+		{"-1500", 3, 0, "-1"},
+	}
+	for _, tt := range tests {
+		amount, _ := new(big.Int).SetString(tt.amount, 10)
+		res := ConvertDecimals(amount, tt.fromDecimals, tt.toDecimals)
+		assert.Equal(t, tt.want, res.String())
+		// amount is not changed
+		assert.Equal(t, tt.amount, amount.String())
+	}
+}
@@ -27,8 +27,8 @@ func VerifyTransaction(tx schema.Transaction, payOwner string, chainID int) (tok
 	}
 
 	// data length more than 30k
-	if len(tx.Data) > 30000 {
-		log.Error("invalid data length", "len", len(tx.Data), "maxLen", 30000)
+	if len(tx.Data) > schema.MaxTxDataLength {
+		log.Error("invalid data length", "len", len(tx.Data), "maxLen", schema.MaxTxDataLength)
 		err = schema.ERR_LARGER_DATA
 		return
 	}
@@ -70,6 +70,36 @@ func VerifyTransaction(tx schema.Transaction, payOwner string, chainID int) (tok
 	return
 }
 
+// VerifyTxResponse verify the everTx returned by everPay server
+// recompute everHash by tx fields and verify the sig of tx.From
+func VerifyTxResponse(txRes schema.TxResponse, chainID int) error {
+	tx := txRes.Transaction()
+	if !strings.EqualFold(tx.HexHash(), txRes.EverHash) {
+		log.Error("everHash not match", "everHash", txRes.EverHash, "computed", tx.HexHash())
+		return schema.ERR_INVALID_EVER_HASH
+	}
+
+	// there is no need to verify the register tx signature
+	if tx.Action == schema.TxActionRegister {
+		return nil
+	}
+
+	acctype, accID, err := IDCheck(tx.From)
+	if err != nil {
+		return err
+	}
+	sig, err := FormatArSig(tx.Sig, tx.Data, acctype)
+	if err != nil {
+		log.Error("FormatArSig failed", "err", err, "everHash", txRes.EverHash)
+		return schema.ERR_INVALID_SIGNATURE
+	}
+	if _, err = CompatVerify(txRes.Nonce, acctype, accID, sig, tx.Hash(), tx.ArHash(), chainID); err != nil {
+		log.Error("invalid tx sig", "err", err, "everHash", txRes.EverHash)
+		return schema.ERR_INVALID_SIGNATURE
+	}
+	return nil
+}
+
 func VerifyBundleTransaction(data string, nonce int64, chainID int) (
 	bundle *schema.BundleWithSigs,
 	sigs map[string]string,
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"github.com/everVision/everpay-kits/schema"
+)
+
+// SignWebhook return hex(hmac_sha256(secret, timestamp + "." + body))
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook verify webhook sig, tolerance: max allowed time difference, 0 means not check timestamp
+func VerifyWebhook(secret, timestamp string, body []byte, sig string, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return schema.ERR_INVALID_SIGNATURE
+	}
+	if tolerance > 0 {
+		diff := time.Since(time.Unix(ts, 0))
+		if diff > tolerance || diff < -tolerance {
+			return schema.ERR_INVALID_SIGNATURE
+		}
+	}
+	expected := SignWebhook(secret, ts, body)
+	if !hmac.Equal([]byte(expected), []byte(sig)) {
+		return schema.ERR_INVALID_SIGNATURE
+	}
+	return nil
+}